@@ -0,0 +1,44 @@
+package cachingstoragewithqueue
+
+import "time"
+
+// коэффициент уменьшения количества одновременно выполняемых функций при неуспешном
+// или слишком долгом выполнении
+const adaptiveBackoffRatio = 0.9
+
+// adaptConcurrency изменяет количество одновременно выполняемых функций по алгоритму AIMD,
+// при неуспешном выполнении или превышении заданного времени выполнения количество
+// уменьшается в adaptiveBackoffRatio раз, но не менее чем на 1, иначе, если выполняется
+// не менее половины допустимого количества функций, количество увеличивается на 1.
+// Вызывается до завершения выполнения функции, поэтому она учитывается в количестве
+// выполняющихся функций
+func (c *CacheStorageWithQueue[T]) adaptConcurrency(isSuccess bool, duration time.Duration) {
+	if !c.adaptive.enabled {
+		return
+	}
+
+	c.config.mutex.Lock()
+	defer c.config.mutex.Unlock()
+
+	limit := c.getConcurrency()
+	//размер кэша должен как минимум в два раза превышать количество потоков
+	maxLimit := max(c.adaptive.minLimit, min(c.adaptive.maxLimit, c.cache.getMaxSize()/2))
+
+	switch {
+	case !isSuccess || duration > c.adaptive.targetLatency:
+		limit = max(1, min(limit-1, int(float64(limit)*adaptiveBackoffRatio)))
+
+	case c.getRunning()*2 >= limit:
+		limit++
+	}
+
+	c.isAsync.Store(int64(min(max(limit, c.adaptive.minLimit), maxLimit)))
+}
+
+// getRunning количество выполняющихся в настоящее время функций
+func (c *CacheStorageWithQueue[T]) getRunning() int {
+	c.running.mutex.Lock()
+	defer c.running.mutex.Unlock()
+
+	return len(c.running.executions)
+}
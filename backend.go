@@ -0,0 +1,296 @@
+package cachingstoragewithqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"path/filepath"
+
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/kvfile"
+)
+
+// cacheBackend хранилище объектов сегмента кэша. Логика планирования выполнения и
+// индексы по времени истечения жизни объектов не зависят от хранилища, поэтому
+// одинаково работают с любой его реализацией. Синхронизация доступа выполняется
+// блокировкой сегмента: get, len и all вызываются под блокировкой на чтение,
+// остальные методы под блокировкой на запись
+type cacheBackend[T any] interface {
+	//get возвращает параметры объекта по ключу
+	get(key string) (storageParameters[T], bool)
+	//put добавляет или заменяет параметры объекта
+	put(key string, storage storageParameters[T])
+	//delete удаляет объект
+	delete(key string)
+	//len количество объектов
+	len() int
+	//all все объекты в произвольном порядке
+	all() iter.Seq2[string, storageParameters[T]]
+	//states параметры всех объектов в произвольном порядке, параметры могут не содержать
+	//самих объектов, их предыдущих версий и результатов выполнения функций, используется
+	//когда нужны только статус выполнения и другие параметры объектов
+	states() iter.Seq2[string, storageParameters[T]]
+	//clear удаляет все объекты
+	clear()
+	//close освобождает ресурсы хранилища
+	close() error
+}
+
+// backendOptions параметры хранилища объектов кэша
+type backendOptions[T any] struct {
+	//директория файлов хранилища, если не задана объекты хранятся в памяти
+	dir   string
+	codec Codec[T]
+}
+
+// memoryBackend хранилище объектов в памяти, используется по умолчанию
+type memoryBackend[T any] map[string]storageParameters[T]
+
+func (mb memoryBackend[T]) get(key string) (storageParameters[T], bool) {
+	storage, ok := mb[key]
+
+	return storage, ok
+}
+
+func (mb memoryBackend[T]) put(key string, storage storageParameters[T]) {
+	mb[key] = storage
+}
+
+func (mb memoryBackend[T]) delete(key string) {
+	delete(mb, key)
+}
+
+func (mb memoryBackend[T]) len() int {
+	return len(mb)
+}
+
+func (mb memoryBackend[T]) all() iter.Seq2[string, storageParameters[T]] {
+	return func(yield func(string, storageParameters[T]) bool) {
+		for key, storage := range mb {
+			if !yield(key, storage) {
+				return
+			}
+		}
+	}
+}
+
+func (mb memoryBackend[T]) states() iter.Seq2[string, storageParameters[T]] {
+	return mb.all()
+}
+
+func (mb memoryBackend[T]) clear() {
+	clear(mb)
+}
+
+func (mb memoryBackend[T]) close() error {
+	return nil
+}
+
+// fileRecord данные объекта, хранящиеся в файле, остальные параметры объекта находятся в памяти
+type fileRecord struct {
+	Object  []byte                `json:"object"`
+	Result  json.RawMessage       `json:"result,omitempty"`
+	History []snapshotVersionItem `json:"history,omitempty"`
+}
+
+// fileBackend хранилище объектов в файле, в файле хранятся только сами объекты, их
+// предыдущие версии и результаты выполнения функций, а статус выполнения, время
+// истечения жизни и остальные параметры объектов, включая функции-обёртки выполнения,
+// которые не могут быть сохранены в файл, находятся в памяти. Поэтому перебор параметров
+// объектов методом states не требует чтения файла
+type fileBackend[T any] struct {
+	store *kvfile.Store
+	codec Codec[T]
+	//параметры объектов без данных, хранящихся в файле
+	params map[string]storageParameters[T]
+	//обработка ошибок чтения и записи файла
+	onError func(error)
+}
+
+// newFileBackend создает файловое хранилище объектов в файле path
+func newFileBackend[T any](path string, codec Codec[T], onError func(error)) (*fileBackend[T], error) {
+	store, err := kvfile.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileBackend[T]{
+		store:   store,
+		codec:   codec,
+		params:  map[string]storageParameters[T]{},
+		onError: onError,
+	}, nil
+}
+
+func (fb *fileBackend[T]) get(key string) (storageParameters[T], bool) {
+	storage, ok := fb.params[key]
+	if !ok {
+		return storageParameters[T]{}, false
+	}
+
+	b, ok, err := fb.store.Get(key)
+	if err != nil || !ok {
+		if err != nil {
+			fb.onError(err)
+		}
+
+		return storageParameters[T]{}, false
+	}
+
+	var record fileRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		fb.onError(fmt.Errorf("error decoding a cache object with key ID '%s': %w", key, err))
+
+		return storageParameters[T]{}, false
+	}
+
+	obj, err := fb.codec.Decode(record.Object)
+	if err != nil {
+		fb.onError(fmt.Errorf("error decoding a cache object with key ID '%s': %w", key, err))
+
+		return storageParameters[T]{}, false
+	}
+
+	history, err := decodeHistory(fb.codec, key, record.History)
+	if err != nil {
+		fb.onError(err)
+
+		return storageParameters[T]{}, false
+	}
+
+	storage.originalObject = obj
+	storage.result = decodeResult(record.Result)
+	storage.history = history
+
+	return storage, true
+}
+
+func (fb *fileBackend[T]) put(key string, storage storageParameters[T]) {
+	obj, err := fb.codec.Encode(storage.originalObject)
+	if err != nil {
+		fb.onError(fmt.Errorf("error encoding a cache object with key ID '%s': %w", key, err))
+
+		return
+	}
+
+	record := fileRecord{Object: obj}
+	if record.Result, err = encodeResult(key, storage.result); err != nil {
+		fb.onError(err)
+
+		return
+	}
+
+	if record.History, err = encodeHistory(fb.codec, key, storage.history); err != nil {
+		fb.onError(err)
+
+		return
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		fb.onError(fmt.Errorf("error encoding a cache object with key ID '%s': %w", key, err))
+
+		return
+	}
+
+	if err := fb.store.Put(key, b); err != nil {
+		fb.onError(err)
+
+		return
+	}
+
+	fb.params[key] = storage.withoutPayload()
+}
+
+func (fb *fileBackend[T]) delete(key string) {
+	if err := fb.store.Delete(key); err != nil {
+		fb.onError(err)
+	}
+
+	delete(fb.params, key)
+}
+
+func (fb *fileBackend[T]) len() int {
+	return len(fb.params)
+}
+
+func (fb *fileBackend[T]) all() iter.Seq2[string, storageParameters[T]] {
+	return func(yield func(string, storageParameters[T]) bool) {
+		for key := range fb.params {
+			storage, ok := fb.get(key)
+			if !ok {
+				continue
+			}
+
+			if !yield(key, storage) {
+				return
+			}
+		}
+	}
+}
+
+func (fb *fileBackend[T]) states() iter.Seq2[string, storageParameters[T]] {
+	return func(yield func(string, storageParameters[T]) bool) {
+		for key, storage := range fb.params {
+			if !yield(key, storage) {
+				return
+			}
+		}
+	}
+}
+
+func (fb *fileBackend[T]) clear() {
+	if err := fb.store.Clear(); err != nil {
+		fb.onError(err)
+	}
+
+	clear(fb.params)
+}
+
+func (fb *fileBackend[T]) close() error {
+	return fb.store.Close()
+}
+
+// withoutPayload параметры объекта без самого объекта, его предыдущих версий и результата
+// выполнения функции
+func (sp storageParameters[T]) withoutPayload() storageParameters[T] {
+	var obj T
+	sp.originalObject = obj
+	sp.history = nil
+	sp.result = nil
+
+	return sp
+}
+
+// openFileBackends заменяет хранилища всех сегментов кэша файловыми, по одному файлу
+// на сегмент, вызывается до добавления объектов в кэш
+func (c *CacheStorageWithQueue[T]) openFileBackends() error {
+	onError := func(err error) {
+		c.logError(logEventCacheFileError, err)
+	}
+
+	for i, sh := range c.cache.shards {
+		fb, err := newFileBackend(filepath.Join(c.backend.dir, fmt.Sprintf("shard-%03d.db", i)), c.backend.codec, onError)
+		if err != nil {
+			return err
+		}
+
+		sh.storages = fb
+	}
+
+	return nil
+}
+
+// closeBackends освобождает ресурсы хранилищ всех сегментов кэша
+func (c *CacheStorageWithQueue[T]) closeBackends() error {
+	c.cache.lockAll()
+	defer c.cache.unlockAll()
+
+	var err error
+	for _, sh := range c.cache.shards {
+		if errClose := sh.storages.close(); err == nil {
+			err = errClose
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,147 @@
+package cachingstoragewithqueue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// batchExecution выполняет групповую обработку объектов из кэша. Объекты из очереди
+// добавляются в кэш, затем ожидающие выполнения объекты группируются и передаются
+// групповому обработчику одним вызовом. Группа выполняется, если она заполнена или
+// самый старый объект группы ожидает дольше заданного времени ожидания заполнения
+func (c *CacheStorageWithQueue[T]) batchExecution(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	//одновременно выполняется не более одной группы объектов
+	if c.batch.running.Load() {
+		return
+	}
+
+	for range c.batch.maxSize {
+		if c.GetCacheSize() >= c.cache.getMaxSize() {
+			break
+		}
+
+		object, isEmpty := c.PullObjectFromQueue()
+		if isEmpty {
+			break
+		}
+
+		if err := c.AddObjectToCache(object.GetID(), object); err != nil {
+			c.log(slog.LevelWarn, logEventAdmissionRejected, slog.String("id", object.GetID()), slog.Any("error", err))
+		}
+	}
+
+	keys, oldest := c.readyBatch(c.batch.maxSize)
+	if len(keys) == 0 {
+		return
+	}
+
+	//группа не заполнена, ожидание новых объектов
+	if len(keys) < c.batch.maxSize && time.Since(oldest) < c.batch.linger {
+		return
+	}
+
+	items := make([]Item[T], 0, len(keys))
+	generations := make([]uint64, 0, len(keys))
+	for _, key := range keys {
+		storage, ok := c.markExecution(key)
+		if !ok {
+			continue
+		}
+
+		items = append(items, Item[T]{ID: key, Object: storage.originalObject, Attempt: storage.numberExecutionAttempts})
+		generations = append(generations, storage.generation)
+	}
+
+	if len(items) == 0 {
+		return
+	}
+
+	c.batch.running.Store(true)
+	go func() {
+		defer c.batch.running.Store(false)
+
+		c.executeBatch(ctx, items, generations)
+	}()
+}
+
+// executeBatch вызывает групповой обработчик и изменяет состояние каждого объекта группы,
+// generations номера запусков объектов группы
+func (c *CacheStorageWithQueue[T]) executeBatch(ctx context.Context, items []Item[T], generations []uint64) {
+	start := time.Now()
+	errs := c.batch.handler(ctx, items)
+	duration := time.Since(start)
+	c.metrics.handlerDuration.Observe(duration.Seconds())
+
+	//количество результатов не совпадает с количеством объектов, результат
+	//выполнения объектов неизвестен
+	if len(errs) != len(items) {
+		err := fmt.Errorf("the batch handler returned %d results for %d objects", len(errs), len(items))
+		errs = slices.Repeat([]error{err}, len(items))
+	}
+
+	for i, item := range items {
+		if errs[i] == nil {
+			c.log(slog.LevelDebug, logEventExecuted, slog.String("id", item.ID), slog.Int("attempt", item.Attempt), slog.Duration("duration", duration))
+		} else {
+			c.log(slog.LevelInfo, logEventExecutionFailed, slog.String("id", item.ID), slog.Int("attempt", item.Attempt), slog.Duration("duration", duration), slog.Any("error", errs[i]))
+		}
+
+		c.completeExecution(item.ID, generations[i], errs[i] == nil, errs[i])
+	}
+}
+
+// readyBatch возвращает не более size ключей объектов ожидающих выполнения, в порядке
+// возрастания времени истечения их жизни, и самое раннее время добавления этих объектов
+// в кэш
+func (c *CacheStorageWithQueue[T]) readyBatch(size int) ([]string, time.Time) {
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
+
+	//из каждого сегмента выбирается не более size объектов с наименьшим временем истечения
+	//жизни, которые затем объединяются в порядке возрастания этого времени
+	lists := make([][]*expiryItem, len(c.cache.shards))
+	for i, sh := range c.cache.shards {
+		lists[i] = sh.ready.smallest(size)
+	}
+
+	var oldest time.Time
+	keys := make([]string, 0, size)
+	for len(keys) < size {
+		shard := -1
+		for i, list := range lists {
+			if len(list) == 0 {
+				continue
+			}
+
+			if shard < 0 || list[0].timeExpiry.Before(lists[shard][0].timeExpiry) {
+				shard = i
+			}
+		}
+		if shard < 0 {
+			break
+		}
+
+		item := lists[shard][0]
+		lists[shard] = lists[shard][1:]
+
+		storage, ok := c.cache.shards[shard].storages.get(item.key)
+		if !ok {
+			continue
+		}
+
+		if oldest.IsZero() || storage.timeMain.Before(oldest) {
+			oldest = storage.timeMain
+		}
+
+		keys = append(keys, item.key)
+	}
+
+	return keys, oldest
+}
@@ -0,0 +1,240 @@
+package cachingstoragewithqueue
+
+import "time"
+
+const (
+	//параметры хеш-функции FNV-1a
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+// newCacheShards создает заданное количество пустых сегментов кэша
+func newCacheShards[T any](count int) []*cacheShard[T] {
+	shards := make([]*cacheShard[T], 0, count)
+	for range count {
+		shards = append(shards, &cacheShard[T]{
+			storages:  memoryBackend[T]{},
+			expiry:    newExpiryIndex(),
+			ready:     newExpiryIndex(),
+			recurring: newExpiryIndex(),
+		})
+	}
+
+	return shards
+}
+
+// shard возвращает сегмент кэша в котором хранится объект с заданным ключом
+func (cs *cacheStorages[T]) shard(key string) *cacheShard[T] {
+	if len(cs.shards) == 1 {
+		return cs.shards[0]
+	}
+
+	//хеш FNV-1a вычисляется без выделения памяти
+	var h uint32 = fnvOffset32
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= fnvPrime32
+	}
+
+	return cs.shards[h%uint32(len(cs.shards))]
+}
+
+// lockAll блокирует на запись все сегменты кэша, блокировка всегда выполняется
+// в одном и том же порядке, что исключает взаимную блокировку
+func (cs *cacheStorages[T]) lockAll() {
+	for _, sh := range cs.shards {
+		sh.mutex.Lock()
+	}
+}
+
+// unlockAll снимает блокировку на запись со всех сегментов кэша
+func (cs *cacheStorages[T]) unlockAll() {
+	for i := len(cs.shards) - 1; i >= 0; i-- {
+		cs.shards[i].mutex.Unlock()
+	}
+}
+
+// rLockAll блокирует на чтение все сегменты кэша
+func (cs *cacheStorages[T]) rLockAll() {
+	for _, sh := range cs.shards {
+		sh.mutex.RLock()
+	}
+}
+
+// rUnlockAll снимает блокировку на чтение со всех сегментов кэша
+func (cs *cacheStorages[T]) rUnlockAll() {
+	for i := len(cs.shards) - 1; i >= 0; i-- {
+		cs.shards[i].mutex.RUnlock()
+	}
+}
+
+// size общее количество объектов во всех сегментах, блокировка сегментов
+// должна быть выполнена вызывающей стороной
+func (cs *cacheStorages[T]) size() int {
+	var size int
+	for _, sh := range cs.shards {
+		size += sh.storages.len()
+	}
+
+	return size
+}
+
+// oldest возвращает ключ и сегмент объекта с наименьшим timeExpiry среди всех
+// сегментов, блокировка сегментов должна быть выполнена вызывающей стороной
+func (cs *cacheStorages[T]) oldest() (string, *cacheShard[T]) {
+	return cs.minFromIndexes(func(sh *cacheShard[T]) *expiryIndex { return sh.expiry })
+}
+
+// oldestReady возвращает ключ и сегмент объекта ожидающего выполнения с наименьшим
+// timeExpiry среди всех сегментов, блокировка сегментов должна быть выполнена
+// вызывающей стороной
+func (cs *cacheStorages[T]) oldestReady() (string, *cacheShard[T]) {
+	return cs.minFromIndexes(func(sh *cacheShard[T]) *expiryIndex { return sh.ready })
+}
+
+// minFromIndexes выбирает наименьший элемент среди вершин индексов всех сегментов
+func (cs *cacheStorages[T]) minFromIndexes(index func(*cacheShard[T]) *expiryIndex) (string, *cacheShard[T]) {
+	var (
+		key        string
+		shard      *cacheShard[T]
+		timeExpiry time.Time
+	)
+
+	for _, sh := range cs.shards {
+		k, te, ok := index(sh).peek()
+		if !ok {
+			continue
+		}
+
+		if shard == nil || te.Before(timeExpiry) {
+			key, shard, timeExpiry = k, sh, te
+		}
+	}
+
+	return key, shard
+}
+
+// set добавляет или обновляет объект в сегменте, с обновлением индексов по времени
+// истечения жизни объектов
+func (sh *cacheShard[T]) set(key string, storage storageParameters[T]) {
+	sh.storages.put(key, storage)
+
+	if storage.schedule != nil {
+		sh.setRecurring(key, storage)
+
+		return
+	}
+
+	sh.expiry.set(key, storage.timeExpiry)
+
+	//в индекс объектов ожидающих выполнения попадают только объекты функция которых
+	//не выполняется в настоящее время и не была успешно выполнена
+	if storage.isExecution || storage.isCompletedSuccessfully {
+		sh.ready.remove(key)
+
+		return
+	}
+
+	sh.ready.set(key, storage.timeExpiry)
+}
+
+// setRecurring обновляет индексы периодического объекта, периодические объекты не удаляются
+// по истечении времени жизни и при достижении максимального размера кэша, поэтому в индекс
+// всех объектов сегмента не попадают. До наступления времени следующего запуска объект
+// находится в индексе периодических объектов, а во время запуска, пока функция объекта не
+// выполнена успешно, в индексе объектов ожидающих выполнения, с нулевым временем, поэтому
+// выбирается для выполнения раньше остальных объектов
+func (sh *cacheShard[T]) setRecurring(key string, storage storageParameters[T]) {
+	sh.expiry.remove(key)
+
+	if !storage.timeNextRun.IsZero() {
+		sh.ready.remove(key)
+		sh.recurring.set(key, storage.timeNextRun)
+
+		return
+	}
+
+	sh.recurring.remove(key)
+	if storage.isExecution || storage.isCompletedSuccessfully {
+		sh.ready.remove(key)
+
+		return
+	}
+
+	sh.ready.set(key, time.Time{})
+}
+
+// setRestored добавляет объект, восстановленный из журнала предзаписи или снимка состояния.
+// Функция объекта, который не был выполнен успешно до остановки кэша, должна быть выполнена
+// снова, поэтому объект ставится первым в индекс объектов ожидающих выполнения, иначе
+// асинхронный обработчик, выбирающий кроме новых объектов только объекты с нулевым временем
+// в этом индексе, его не выполнит
+func (sh *cacheShard[T]) setRestored(key string, storage storageParameters[T]) {
+	sh.set(key, storage)
+
+	if storage.schedule == nil && !storage.isExecution && !storage.isCompletedSuccessfully {
+		sh.ready.set(key, time.Time{})
+	}
+}
+
+// del удаляет объект из сегмента и индексов
+func (sh *cacheShard[T]) del(key string) {
+	sh.storages.delete(key)
+	sh.expiry.remove(key)
+	sh.ready.remove(key)
+	sh.recurring.remove(key)
+}
+
+// clean очистка сегмента и индексов
+func (sh *cacheShard[T]) clean() {
+	sh.storages.clear()
+	sh.expiry.clean()
+	sh.ready.clean()
+	sh.recurring.clean()
+}
+
+// update изменяет параметры объекта с заданным ключом, если такой объект есть в сегменте
+func (sh *cacheShard[T]) update(key string, f func(*storageParameters[T])) {
+	if storage, ok := sh.storages.get(key); ok {
+		f(&storage)
+		sh.set(key, storage)
+	}
+}
+
+// setTimeExpiry устанавливает или обновляет значение параметра timeExpiry
+func (sh *cacheShard[T]) setTimeExpiry(key string, maxTtl time.Duration) {
+	sh.update(key, func(storage *storageParameters[T]) {
+		storage.timeExpiry = time.Now().Add(maxTtl)
+	})
+}
+
+// setIsExecution устанавливает значение параметра isExecution
+func (sh *cacheShard[T]) setIsExecution(key string, v bool) {
+	sh.update(key, func(storage *storageParameters[T]) {
+		storage.isExecution = v
+	})
+}
+
+// setIsCompletedSuccessfully устанавливает значение параметра isCompletedSuccessfully
+func (sh *cacheShard[T]) setIsCompletedSuccessfully(key string, v bool) {
+	sh.update(key, func(storage *storageParameters[T]) {
+		storage.isCompletedSuccessfully = v
+	})
+}
+
+// startExecution меняет статус выполнения функции на 'функция в обработке', увеличивает
+// кол-во попыток обработки функции на 1, запоминает номер запуска и возвращает параметры
+// объекта
+func (sh *cacheShard[T]) startExecution(key string, generation uint64) (storageParameters[T], bool) {
+	storage, ok := sh.storages.get(key)
+	if !ok {
+		return storage, false
+	}
+
+	storage.isExecution = true
+	storage.generation = generation
+	storage.numberExecutionAttempts = storage.numberExecutionAttempts + 1
+	sh.set(key, storage)
+
+	return storage, true
+}
@@ -146,6 +146,9 @@ func main() {
 
 	log.Println("Package 'cachestoragewithqueue' is start")
 
+	//метрики хранилища в формате Prometheus, рядом с pprof
+	http.Handle("/metrics", cache.MetricsHandler("example"))
+
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil))
 	}()
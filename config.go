@@ -0,0 +1,177 @@
+package cachingstoragewithqueue
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	//наибольший размер кэша, объекты которого хранятся в памяти
+	maxMemorySize = 1000
+	//наибольший размер кэша, объекты которого хранятся в файлах
+	maxFileBackendSize = 10000000
+)
+
+// Config возвращает текущие параметры хранилища
+func (c *CacheStorageWithQueue[T]) Config() Config {
+	return Config{
+		MaxTtl:      c.getMaxTtl(),
+		TimeTick:    c.getTimeTick(),
+		MaxSize:     c.cache.getMaxSize(),
+		Concurrency: c.getConcurrency(),
+	}
+}
+
+// SetMaxSize изменяет максимальный размер кэша во время работы хранилища, значение проверяется
+// так же как в опции WithMaxSize. Если размер кэша превышает новое значение, лишние объекты
+// удаляются циклом автоматической обработки по мере их выполнения
+func (c *CacheStorageWithQueue[T]) SetMaxSize(v int) error {
+	if err := validateMaxSize(v, c.maxSizeLimit()); err != nil {
+		return err
+	}
+
+	c.config.mutex.Lock()
+	defer c.config.mutex.Unlock()
+
+	if err := validateConcurrency(v, c.getConcurrency()); err != nil {
+		return err
+	}
+
+	c.cache.maxSize.Store(int64(v))
+
+	return nil
+}
+
+// SetConcurrency изменяет количество одновременно выполняемых функций во время работы
+// хранилища, аналогично опции WithEnableAsyncProcessing, при значении меньше 2 функции
+// выполняются синхронно. Размер кэша должен минимум в два раза превышать это значение.
+// При адаптивном управлении (WithAdaptiveConcurrency) значение становится новым
+// максимальным количеством, оно не может быть меньше минимального количества, заданного
+// в опции, а текущее количество уменьшается, если превышает новое значение
+func (c *CacheStorageWithQueue[T]) SetConcurrency(v int) error {
+	c.config.mutex.Lock()
+	defer c.config.mutex.Unlock()
+
+	if err := validateConcurrency(c.cache.getMaxSize(), v); err != nil {
+		return err
+	}
+
+	if c.adaptive.enabled {
+		if v < c.adaptive.minLimit {
+			return fmt.Errorf("the number of concurrently running functions cannot be less than the minimum limit %d of the adaptive concurrency", c.adaptive.minLimit)
+		}
+
+		c.adaptive.maxLimit = v
+		c.isAsync.Store(int64(min(c.getConcurrency(), v)))
+
+		return nil
+	}
+
+	c.isAsync.Store(int64(v))
+
+	return nil
+}
+
+// SetTimeTick изменяет интервал автоматической обработки во время работы хранилища, значение
+// проверяется так же как в опции WithTimeTick, новый интервал применяется сразу
+func (c *CacheStorageWithQueue[T]) SetTimeTick(v int) error {
+	if err := validateTimeTick(v); err != nil {
+		return err
+	}
+
+	c.config.mutex.Lock()
+	defer c.config.mutex.Unlock()
+
+	c.timeTick.Store(int64(time.Duration(v) * time.Second))
+
+	select {
+	case c.config.tickChanged <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// SetMaxTtl изменяет максимальное время жизни объекта в кэше во время работы хранилища,
+// значение проверяется так же как в опции WithMaxTtl. Новое время жизни устанавливается
+// для объектов, которые добавляются или заменяются в кэше после изменения
+func (c *CacheStorageWithQueue[T]) SetMaxTtl(v int) error {
+	if err := validateMaxTtl(v); err != nil {
+		return err
+	}
+
+	c.config.mutex.Lock()
+	defer c.config.mutex.Unlock()
+
+	c.maxTtl.Store(int64(time.Duration(v) * time.Second))
+
+	return nil
+}
+
+// getMaxTtl максимальное время жизни объекта в кэше
+func (c *CacheStorageWithQueue[T]) getMaxTtl() time.Duration {
+	return time.Duration(c.maxTtl.Load())
+}
+
+// getTimeTick интервал автоматической обработки
+func (c *CacheStorageWithQueue[T]) getTimeTick() time.Duration {
+	return time.Duration(c.timeTick.Load())
+}
+
+// getConcurrency количество одновременно выполняемых функций
+func (c *CacheStorageWithQueue[T]) getConcurrency() int {
+	return int(c.isAsync.Load())
+}
+
+// getMaxSize максимальный размер кэша
+func (cs *cacheStorages[T]) getMaxSize() int {
+	return int(cs.maxSize.Load())
+}
+
+// validateMaxTtl проверка максимального времени жизни объекта, в секундах
+func validateMaxTtl(v int) error {
+	if v < 60 || v > 86400 {
+		return errors.New("the maximum time after which an entry in the cache will be deleted should not be less than 300 seconds or more than 24 hours (86400 seconds)")
+	}
+
+	return nil
+}
+
+// validateTimeTick проверка интервала автоматической обработки, в секундах
+func validateTimeTick(v int) error {
+	if v < 1 || v > 120 {
+		return errors.New("the set clock cycle time should not be less than 3 seconds or more than 120 seconds")
+	}
+
+	return nil
+}
+
+// validateMaxSize проверка максимального размера кэша, limit наибольший допустимый размер
+func validateMaxSize(v, limit int) error {
+	if v < 3 || v > limit {
+		return fmt.Errorf("the maximum cache size cannot be less than 3 or more than %d objects", limit)
+	}
+
+	return nil
+}
+
+// maxSizeLimit наибольший допустимый размер кэша, при хранении объектов в файлах размер
+// кэша не ограничен объемом оперативной памяти, поэтому он может быть больше
+func (c *CacheStorageWithQueue[T]) maxSizeLimit() int {
+	if c.backend.dir != "" {
+		return maxFileBackendSize
+	}
+
+	return maxMemorySize
+}
+
+// validateConcurrency проверка количества потоков асинхронного выполнения, размер кэша должен
+// как минимум в два раза превышать количество потоков, если асинхронный режим активирован
+func validateConcurrency(maxSize, isAsync int) error {
+	if isAsync >= 2 && (maxSize < isAsync || maxSize/isAsync < 2) {
+		return errors.New("the cache size must be at least twice the number of asynchronous execution threads")
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package cachingstoragewithqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	//ErrNotFound объект с заданным ключом не найден
+	ErrNotFound = errors.New("the object was not found")
+	//ErrWrongState состояние объекта не позволяет выполнить операцию
+	ErrWrongState = errors.New("the state of the object does not allow the operation")
+	//ErrCacheFull кэш достиг максимального размера и ни один объект не может быть удалён
+	ErrCacheFull = errors.New("the cache has reached its maximum size")
+)
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%s the object with key ID '%s': %v", e.Op, e.Key, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryNow сбрасывает количество попыток выполнения и результат выполнения функции объекта
+// находящегося в кэше, объект выполняется при очередном выборе объекта для выполнения,
+// раньше остальных объектов ожидающих выполнения, для периодического объекта очередной
+// запуск начинается вне расписания. Возвращает *EntryError с ErrNotFound,
+// если объекта нет в кэше, или с ErrWrongState, если функция объекта выполняется или
+// объект находится в очереди
+func (c *CacheStorageWithQueue[T]) RetryNow(key string) error {
+	const op = "retry"
+
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	storage, ok := sh.storages.get(key)
+	if !ok {
+		sh.mutex.Unlock()
+
+		return c.notInCacheError(op, key)
+	}
+
+	if storage.isExecution {
+		sh.mutex.Unlock()
+
+		return &EntryError{Op: op, Key: key, Err: fmt.Errorf("%w, the function of the object is being executed", ErrWrongState)}
+	}
+
+	storage.numberExecutionAttempts = 0
+	storage.isCompletedSuccessfully = false
+	storage.lastError = nil
+	//очередной запуск периодического объекта начинается вне расписания
+	storage.timeNextRun = time.Time{}
+	sh.set(key, storage)
+	//объекты ожидающие выполнения выбираются по наименьшему времени в индексе,
+	//нулевое время ставит объект первым
+	sh.ready.set(key, time.Time{})
+	c.walStore(walOpState, key, storage)
+	sh.mutex.Unlock()
+
+	return nil
+}
+
+// Requeue удаляет объект из кэша и добавляет его в конец очереди на обработку, счетчик
+// попыток выполнения функции начинается заново. Возвращает *EntryError с ErrNotFound,
+// если объекта нет в кэше, или с ErrWrongState, если функция объекта выполняется, объект
+// уже находится в очереди или объект периодический
+func (c *CacheStorageWithQueue[T]) Requeue(key string) error {
+	const op = "requeue"
+
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	storage, ok := sh.storages.get(key)
+	if !ok {
+		sh.mutex.Unlock()
+
+		return c.notInCacheError(op, key)
+	}
+
+	if storage.isExecution {
+		sh.mutex.Unlock()
+
+		return &EntryError{Op: op, Key: key, Err: fmt.Errorf("%w, the function of the object is being executed", ErrWrongState)}
+	}
+
+	if storage.schedule != nil {
+		sh.mutex.Unlock()
+
+		return &EntryError{Op: op, Key: key, Err: fmt.Errorf("%w, the object is recurring", ErrWrongState)}
+	}
+
+	sh.del(key)
+	c.walDelete(key)
+	sh.mutex.Unlock()
+
+	c.PushObjectToQueue(&storedHandler[T]{
+		id:          key,
+		object:      storage.originalObject,
+		cacheFunc:   storage.cacheFunc,
+		handlerName: storage.handlerName,
+		hash:        storage.hash,
+	})
+
+	return nil
+}
+
+// Cancel удаляет объект с заданным ключом из очереди и из кэша, если функция объекта
+// выполняется, отменяется контекст её выполнения, который получают функции вспомогательных
+// типов реализующих интерфейс ContextHandler, а результат выполнения не учитывается.
+// Возвращает *EntryError с ErrNotFound, если объекта нет ни в очереди, ни в кэше
+func (c *CacheStorageWithQueue[T]) Cancel(key string) error {
+	var removed int
+
+	c.queue.mutex.Lock()
+	c.queue.deleteFunc(func(item queueItem[T]) bool {
+		if item.handler.GetID() != key {
+			return false
+		}
+
+		removed++
+		c.walOp(walOpCancel, key)
+
+		return true
+	})
+	c.queue.mutex.Unlock()
+
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	if _, ok := sh.storages.get(key); ok {
+		sh.del(key)
+		c.walDelete(key)
+		removed++
+	}
+	sh.mutex.Unlock()
+
+	c.finishExecution(key, 0)
+
+	if removed == 0 {
+		return &EntryError{Op: "cancel", Key: key, Err: ErrNotFound}
+	}
+
+	return nil
+}
+
+// notInCacheError ошибка операции с объектом, которого нет в кэше
+func (c *CacheStorageWithQueue[T]) notInCacheError(op, key string) error {
+	c.queue.mutex.RLock()
+	var isQueued bool
+	for item := range c.queue.all() {
+		if item.handler.GetID() == key {
+			isQueued = true
+
+			break
+		}
+	}
+	c.queue.mutex.RUnlock()
+
+	if isQueued {
+		return &EntryError{Op: op, Key: key, Err: fmt.Errorf("%w, the object is in the queue", ErrWrongState)}
+	}
+
+	return &EntryError{Op: op, Key: key, Err: ErrNotFound}
+}
+
+// objectFunc возвращает исполняемую функцию вспомогательного типа, функции вспомогательных
+// типов реализующих интерфейсы ResultHandler и ContextHandler получают контекст выполнения
+// объекта, а результат выполнения функции ResultHandler сохраняется в кэше
+func (c *CacheStorageWithQueue[T]) objectFunc(key string, value CacheStorageHandler[T]) func(int) bool {
+	if rh, ok := value.(ResultHandler); ok {
+		if f := rh.GetResultFunc(); f != nil {
+			return func(int) bool {
+				ctx, generation := c.executionContext(key)
+				result, isSuccess := f(ctx)
+				c.storeResult(key, generation, result)
+
+				return isSuccess
+			}
+		}
+	}
+
+	if ch, ok := value.(ContextHandler); ok {
+		if f := ch.GetContextFunc(); f != nil {
+			return func(int) bool {
+				ctx, _ := c.executionContext(key)
+
+				return f(ctx)
+			}
+		}
+	}
+
+	return value.GetFunc()
+}
+
+// beginExecution создает контекст выполнения функции объекта для запуска с номером generation
+func (c *CacheStorageWithQueue[T]) beginExecution(key string, generation uint64) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.running.mutex.Lock()
+	defer c.running.mutex.Unlock()
+
+	if c.running.executions == nil {
+		c.running.executions = map[string]execution{}
+	}
+
+	if e, ok := c.running.executions[key]; ok {
+		e.cancel()
+	}
+	c.running.executions[key] = execution{ctx: ctx, cancel: cancel, generation: generation}
+}
+
+// finishExecution отменяет и удаляет контекст выполнения функции объекта, если generation
+// не 0, контекст удаляется только если он создан для запуска с этим номером
+func (c *CacheStorageWithQueue[T]) finishExecution(key string, generation uint64) {
+	c.running.mutex.Lock()
+	defer c.running.mutex.Unlock()
+
+	if e, ok := c.running.executions[key]; ok && (generation == 0 || e.generation == generation) {
+		e.cancel()
+		delete(c.running.executions, key)
+	}
+}
+
+// executionContext возвращает контекст выполнения функции объекта и номер запуска
+func (c *CacheStorageWithQueue[T]) executionContext(key string) (context.Context, uint64) {
+	c.running.mutex.Lock()
+	defer c.running.mutex.Unlock()
+
+	if e, ok := c.running.executions[key]; ok {
+		return e.ctx, e.generation
+	}
+
+	return context.Background(), 0
+}
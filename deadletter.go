@@ -0,0 +1,275 @@
+package cachingstoragewithqueue
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// максимальное количество попыток выполнения функции объекта, после которого объект
+// может быть удалён из кэша как неуспешно выполненный
+const maxExecutionAttempts = 3
+
+// deadLetterQueue очередь недоставленных объектов, хранит объекты функции которых
+// выполнялись, но не были выполнены успешно, после их удаления из кэша
+type deadLetterQueue[T any] struct {
+	mutex sync.Mutex
+	items map[string]deadLetterItem[T]
+	//ключи упорядоченные по времени удаления из очереди
+	index   *expiryIndex
+	ttl     time.Duration
+	maxSize int
+}
+
+// deadLetterItem объект очереди недоставленных объектов вместе с параметрами, которые
+// нужны для его повторного добавления в очередь на обработку
+type deadLetterItem[T any] struct {
+	info      DeadLetter[T]
+	cacheFunc func(int) bool
+	hash      []byte
+}
+
+// newDeadLetterQueue новая очередь недоставленных объектов
+func newDeadLetterQueue[T any](ttl time.Duration, maxSize int) *deadLetterQueue[T] {
+	return &deadLetterQueue[T]{
+		items:   map[string]deadLetterItem[T]{},
+		index:   newExpiryIndex(),
+		ttl:     ttl,
+		maxSize: maxSize,
+	}
+}
+
+// add добавляет объект удаляемый из кэша, при превышении максимального размера очереди
+// удаляется объект, который был бы удален раньше остальных
+func (dq *deadLetterQueue[T]) add(key string, storage storageParameters[T], reason Reason) {
+	dq.mutex.Lock()
+	defer dq.mutex.Unlock()
+
+	now := time.Now()
+	dq.removeExpired(now)
+
+	dq.items[key] = deadLetterItem[T]{
+		info: DeadLetter[T]{
+			Object:                  storage.originalObject,
+			TimeMain:                storage.timeMain,
+			TimeDead:                now,
+			TimeExpiry:              now.Add(dq.ttl),
+			LastError:               storage.lastError,
+			ID:                      key,
+			HandlerName:             storage.handlerName,
+			Reason:                  reason,
+			NumberExecutionAttempts: storage.numberExecutionAttempts,
+		},
+		cacheFunc: storage.cacheFunc,
+		hash:      storage.hash,
+	}
+	dq.index.set(key, now.Add(dq.ttl))
+
+	for dq.index.len() > dq.maxSize {
+		oldest, _, _ := dq.index.peek()
+		dq.remove(oldest)
+	}
+}
+
+// get возвращает объект по ключу
+func (dq *deadLetterQueue[T]) get(key string) (deadLetterItem[T], bool) {
+	dq.mutex.Lock()
+	defer dq.mutex.Unlock()
+
+	dq.removeExpired(time.Now())
+	item, ok := dq.items[key]
+
+	return item, ok
+}
+
+// take возвращает объект по ключу и удаляет его из очереди
+func (dq *deadLetterQueue[T]) take(key string) (deadLetterItem[T], bool) {
+	dq.mutex.Lock()
+	defer dq.mutex.Unlock()
+
+	dq.removeExpired(time.Now())
+	item, ok := dq.items[key]
+	if ok {
+		dq.remove(key)
+	}
+
+	return item, ok
+}
+
+// list возвращает все объекты упорядоченные по времени перемещения в очередь
+func (dq *deadLetterQueue[T]) list() []DeadLetter[T] {
+	dq.mutex.Lock()
+	defer dq.mutex.Unlock()
+
+	dq.removeExpired(time.Now())
+
+	list := make([]DeadLetter[T], 0, len(dq.items))
+	for _, item := range dq.items {
+		list = append(list, item.info)
+	}
+
+	slices.SortFunc(list, func(a, b DeadLetter[T]) int {
+		return a.TimeDead.Compare(b.TimeDead)
+	})
+
+	return list
+}
+
+// purge удаляет объекты с заданными ключами или все объекты, если ключи не заданы,
+// возвращает количество удаленных объектов
+func (dq *deadLetterQueue[T]) purge(keys ...string) int {
+	dq.mutex.Lock()
+	defer dq.mutex.Unlock()
+
+	if len(keys) == 0 {
+		num := len(dq.items)
+		clear(dq.items)
+		dq.index.clean()
+
+		return num
+	}
+
+	var num int
+	for _, key := range keys {
+		if _, ok := dq.items[key]; ok {
+			dq.remove(key)
+			num++
+		}
+	}
+
+	return num
+}
+
+// removeExpired удаляет объекты время хранения которых истекло
+func (dq *deadLetterQueue[T]) removeExpired(now time.Time) {
+	for {
+		oldest, timeExpiry, ok := dq.index.peek()
+		if !ok || !timeExpiry.Before(now) {
+			break
+		}
+
+		dq.remove(oldest)
+	}
+}
+
+// remove удаляет объект из очереди и индекса
+func (dq *deadLetterQueue[T]) remove(key string) {
+	delete(dq.items, key)
+	dq.index.remove(key)
+}
+
+// GetDeadLetters возвращает объекты из очереди недоставленных объектов, упорядоченные по
+// времени их перемещения в очередь. Если очередь недоставленных объектов не включена
+// опцией WithDeadLetterQueue, возвращается nil
+func (c *CacheStorageWithQueue[T]) GetDeadLetters() []DeadLetter[T] {
+	if c.dead == nil {
+		return nil
+	}
+
+	return c.dead.list()
+}
+
+// GetDeadLetter возвращает объект из очереди недоставленных объектов по ключу
+func (c *CacheStorageWithQueue[T]) GetDeadLetter(key string) (DeadLetter[T], bool) {
+	if c.dead == nil {
+		return DeadLetter[T]{}, false
+	}
+
+	item, ok := c.dead.get(key)
+
+	return item.info, ok
+}
+
+// RequeueDeadLetter удаляет объект из очереди недоставленных объектов и добавляет его
+// в очередь на обработку. Объект выполняется той же функцией или тем же зарегистрированным
+// обработчиком, что и до перемещения в очередь недоставленных объектов, счетчик попыток
+// выполнения функции начинается заново
+func (c *CacheStorageWithQueue[T]) RequeueDeadLetter(key string) error {
+	if c.dead == nil {
+		return errors.New("the dead letter queue is not enabled, use the WithDeadLetterQueue option")
+	}
+
+	item, ok := c.dead.take(key)
+	if !ok {
+		return fmt.Errorf("the object with key ID '%s' was not found in the dead letter queue", key)
+	}
+
+	c.PushObjectToQueue(&storedHandler[T]{
+		id:          key,
+		object:      item.info.Object,
+		cacheFunc:   item.cacheFunc,
+		handlerName: item.info.HandlerName,
+		hash:        item.hash,
+	})
+
+	return nil
+}
+
+// PurgeDeadLetters удаляет из очереди недоставленных объектов объекты с заданными ключами
+// или все объекты, если ключи не заданы, возвращает количество удаленных объектов
+func (c *CacheStorageWithQueue[T]) PurgeDeadLetters(keys ...string) int {
+	if c.dead == nil {
+		return 0
+	}
+
+	return c.dead.purge(keys...)
+}
+
+// isUndelivered функция объекта выполнялась хотя бы один раз, но не была выполнена
+// успешно и в данный момент не выполняется
+func isUndelivered[T any](storage storageParameters[T]) bool {
+	return !storage.isExecution && !storage.isCompletedSuccessfully && storage.numberExecutionAttempts > 0
+}
+
+// storedHandler вспомогательный тип, восстанавливающий объект из сохраненных параметров
+// для повторного добавления в очередь на обработку. Объект из кэша с тем же ключом
+// заменяется восстановленным объектом
+type storedHandler[T any] struct {
+	object      T
+	cacheFunc   func(int) bool
+	id          string
+	handlerName string
+	hash        []byte
+}
+
+func (sh *storedHandler[T]) GetID() string {
+	return sh.id
+}
+
+func (sh *storedHandler[T]) GetFunc() func(int) bool {
+	return sh.cacheFunc
+}
+
+func (sh *storedHandler[T]) GetObject() T {
+	return sh.object
+}
+
+func (sh *storedHandler[T]) SetID(v string) {
+	sh.id = v
+}
+
+func (sh *storedHandler[T]) SetFunc(f func(int) bool) {
+	sh.cacheFunc = f
+}
+
+func (sh *storedHandler[T]) SetObject(v T) {
+	sh.object = v
+}
+
+func (sh *storedHandler[T]) Comparison(T) bool {
+	return false
+}
+
+func (sh *storedHandler[T]) MatchingAndReplacement(T) T {
+	return sh.object
+}
+
+func (sh *storedHandler[T]) Hash() []byte {
+	return sh.hash
+}
+
+func (sh *storedHandler[T]) GetHandlerName() string {
+	return sh.handlerName
+}
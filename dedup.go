@@ -0,0 +1,133 @@
+package cachingstoragewithqueue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/bloomfilter"
+)
+
+// dedupWindow окно дедупликации, хранит ключи успешно выполненных объектов после
+// их удаления из кэша
+type dedupWindow interface {
+	remember(key string)
+	contains(key string) bool
+}
+
+// dedupTombstones точное окно дедупликации, хранит сами ключи
+type dedupTombstones struct {
+	mutex sync.Mutex
+	//ключи упорядоченные по времени их удаления из окна
+	index   *expiryIndex
+	ttl     time.Duration
+	maxSize int
+}
+
+// newDedupTombstones новое точное окно дедупликации
+func newDedupTombstones(ttl time.Duration, maxSize int) *dedupTombstones {
+	return &dedupTombstones{
+		index:   newExpiryIndex(),
+		ttl:     ttl,
+		maxSize: maxSize,
+	}
+}
+
+// remember запоминает ключ на время ttl, при превышении максимального размера окна
+// удаляется ключ, который был бы удален раньше остальных
+func (dt *dedupTombstones) remember(key string) {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	dt.index.set(key, time.Now().Add(dt.ttl))
+	for dt.index.len() > dt.maxSize {
+		oldest, _, _ := dt.index.peek()
+		dt.index.remove(oldest)
+	}
+}
+
+// contains проверяет, есть ли ключ в окне
+func (dt *dedupTombstones) contains(key string) bool {
+	dt.mutex.Lock()
+	defer dt.mutex.Unlock()
+
+	now := time.Now()
+	for {
+		oldest, timeExpiry, ok := dt.index.peek()
+		if !ok || !timeExpiry.Before(now) {
+			break
+		}
+
+		dt.index.remove(oldest)
+	}
+
+	_, ok := dt.index.items[key]
+
+	return ok
+}
+
+// dedupBloom вероятностное окно дедупликации на основе двух поколений фильтров Блума,
+// требует фиксированного объема памяти независимо от количества ключей. Ключ хранится
+// не более двух ttl и не менее ttl, если за это время запоминается не больше expectedItems
+// ключей, иначе поколения сменяются раньше и ключ удаляется после того, как за ним будет
+// запомнено от expectedItems до двух expectedItems ключей. С заданной вероятностью возможны
+// ложноположительные ответы, то есть новый объект может быть принят за уже выполненный
+type dedupBloom struct {
+	mutex    sync.Mutex
+	current  *bloomfilter.BloomFilter
+	previous *bloomfilter.BloomFilter
+	//время создания текущего поколения
+	rotated           time.Time
+	ttl               time.Duration
+	expectedItems     int
+	falsePositiveRate float64
+}
+
+// newDedupBloom новое вероятностное окно дедупликации
+func newDedupBloom(ttl time.Duration, expectedItems int, falsePositiveRate float64) *dedupBloom {
+	return &dedupBloom{
+		current:           bloomfilter.New(expectedItems, falsePositiveRate),
+		previous:          bloomfilter.New(expectedItems, falsePositiveRate),
+		rotated:           time.Now(),
+		ttl:               ttl,
+		expectedItems:     expectedItems,
+		falsePositiveRate: falsePositiveRate,
+	}
+}
+
+// remember запоминает ключ
+func (db *dedupBloom) remember(key string) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.rotate()
+	db.current.Add(key)
+}
+
+// contains проверяет, возможно ли ключ есть в окне
+func (db *dedupBloom) contains(key string) bool {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	db.rotate()
+
+	return db.current.Contains(key) || db.previous.Contains(key)
+}
+
+// rotate смена поколений фильтров, выполняется по истечении ttl или при заполнении
+// текущего поколения ожидаемым количеством ключей, что бы вероятность ложноположительного
+// ответа не превышала заданную. Если с момента создания текущего поколения прошло два
+// и более ttl, ключи обоих поколений устарели и оба поколения очищаются
+func (db *dedupBloom) rotate() {
+	windows := time.Since(db.rotated) / db.ttl
+	if windows < 1 && db.current.Count() < uint64(db.expectedItems) {
+		return
+	}
+
+	if windows >= 2 {
+		db.previous.Reset()
+	} else {
+		db.previous, db.current = db.current, db.previous
+	}
+	db.current.Reset()
+	db.rotated = time.Now()
+}
@@ -0,0 +1,150 @@
+package cachingstoragewithqueue
+
+import (
+	"iter"
+	"slices"
+)
+
+// GetEntry возвращает согласованное состояние объекта в кэше по ключу и найден ли такой объект
+func (c *CacheStorageWithQueue[T]) GetEntry(key string) (EntryInfo[T], bool) {
+	storage, ok := c.getStorageParameters(key)
+	if !ok {
+		return EntryInfo[T]{}, false
+	}
+
+	return storage.entryInfo(key), true
+}
+
+// All возвращает итератор по снимку всех объектов кэша, снимок формируется при
+// начале итерации под блокировкой всех сегментов кэша, поэтому состояния объектов
+// согласованы между собой, а изменения кэша во время итерации на снимок не влияют.
+// Объекты перебираются в порядке возрастания времени истечения жизни
+func (c *CacheStorageWithQueue[T]) All() iter.Seq2[string, EntryInfo[T]] {
+	return func(yield func(string, EntryInfo[T]) bool) {
+		for _, entry := range c.getEntries() {
+			if !yield(entry.ID, entry) {
+				return
+			}
+		}
+	}
+}
+
+// getEntries формирует снимок всех объектов кэша упорядоченный по timeExpiry
+func (c *CacheStorageWithQueue[T]) getEntries() []EntryInfo[T] {
+	c.cache.rLockAll()
+	list := make([]EntryInfo[T], 0, c.cache.size())
+	for _, sh := range c.cache.shards {
+		for key, storage := range sh.storages.all() {
+			list = append(list, storage.entryInfo(key))
+		}
+	}
+	c.cache.rUnlockAll()
+
+	slices.SortFunc(list, func(a, b EntryInfo[T]) int {
+		return a.TimeExpiry.Compare(b.TimeExpiry)
+	})
+
+	return list
+}
+
+// entryInfo формирует публичное представление параметров объекта
+func (sp storageParameters[T]) entryInfo(key string) EntryInfo[T] {
+	return EntryInfo[T]{
+		ID:                      key,
+		HandlerName:             sp.handlerName,
+		Object:                  sp.originalObject,
+		TimeMain:                sp.timeMain,
+		TimeExpiry:              sp.timeExpiry,
+		LastError:               sp.lastError,
+		NumberExecutionAttempts: sp.numberExecutionAttempts,
+		IsCompletedSuccessfully: sp.isCompletedSuccessfully,
+		IsExecution:             sp.isExecution,
+		Schedule:                sp.schedule.String(),
+		TimeNextRun:             sp.timeNextRun,
+		Result:                  sp.result,
+	}
+}
+
+// Find возвращает ключи всех объектов кэша удовлетворяющих условию predicate. Поиск
+// выполняется атомарно, под блокировкой всех сегментов кэша, поэтому predicate не должна
+// вызывать методы хранилища. Ключи упорядочены по возрастанию времени истечения жизни
+func (c *CacheStorageWithQueue[T]) Find(predicate func(EntryInfo[T]) bool) []string {
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
+
+	return c.findKeys(predicate)
+}
+
+// DeleteWhere удаляет из кэша все объекты удовлетворяющие условию predicate и возвращает
+// их ключи. Поиск и удаление выполняются атомарно, под блокировкой всех сегментов кэша,
+// поэтому predicate не должна вызывать методы хранилища. Объекты удаляются в том числе
+// и в том случае если их функция выполняется в настоящее время, контекст выполнения
+// такой функции отменяется, как в методе Cancel
+func (c *CacheStorageWithQueue[T]) DeleteWhere(predicate func(EntryInfo[T]) bool) []string {
+	c.cache.lockAll()
+	keys := c.findKeys(predicate)
+	for _, key := range keys {
+		c.cache.shard(key).del(key)
+	}
+	c.walDelete(keys...)
+	c.cache.unlockAll()
+
+	c.cancelExecutions(keys)
+
+	return keys
+}
+
+// DeleteByKeys удаляет из кэша объекты с заданными ключами и возвращает ключи
+// объектов, которые были найдены и удалены. Если функция объекта выполняется в
+// настоящее время, контекст её выполнения отменяется, как в методе Cancel
+func (c *CacheStorageWithQueue[T]) DeleteByKeys(keys ...string) []string {
+	c.cache.lockAll()
+	deleted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		sh := c.cache.shard(key)
+		if _, ok := sh.storages.get(key); !ok {
+			continue
+		}
+
+		sh.del(key)
+		deleted = append(deleted, key)
+	}
+	c.walDelete(deleted...)
+	c.cache.unlockAll()
+
+	c.cancelExecutions(deleted)
+
+	return deleted
+}
+
+// cancelExecutions отменяет контекст выполнения функций удалённых объектов, вызывается
+// после снятия блокировки сегментов кэша
+func (c *CacheStorageWithQueue[T]) cancelExecutions(keys []string) {
+	for _, key := range keys {
+		c.finishExecution(key, 0)
+	}
+}
+
+// findKeys ключи объектов удовлетворяющих условию, упорядоченные по timeExpiry,
+// блокировка сегментов должна быть выполнена вызывающей стороной
+func (c *CacheStorageWithQueue[T]) findKeys(predicate func(EntryInfo[T]) bool) []string {
+	var list []EntryInfo[T]
+	for _, sh := range c.cache.shards {
+		for key, storage := range sh.storages.all() {
+			if entry := storage.entryInfo(key); predicate(entry) {
+				list = append(list, entry)
+			}
+		}
+	}
+
+	slices.SortFunc(list, func(a, b EntryInfo[T]) int {
+		return a.TimeExpiry.Compare(b.TimeExpiry)
+	})
+
+	keys := make([]string, 0, len(list))
+	for _, entry := range list {
+		keys = append(keys, entry.ID)
+	}
+
+	return keys
+}
@@ -0,0 +1,164 @@
+package cachingstoragewithqueue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// размер буфера подписчика по умолчанию
+const defaultSubscriberBufferSize = 64
+
+// Subscribe подписка на события жизненного цикла объектов, возвращает канал событий,
+// который закрывается при завершении контекста ctx. В канал передаются только события,
+// для которых filter возвращает true, если filter равен nil передаются все события.
+// У каждого подписчика свой буфер, при его заполнении событие по умолчанию отбрасывается,
+// поэтому медленный подписчик не задерживает обработку объектов
+func (c *CacheStorageWithQueue[T]) Subscribe(ctx context.Context, filter func(Event[T]) bool, opts ...SubscribeOption) (<-chan Event[T], error) {
+	settings := subscriptionSettings{bufferSize: defaultSubscriberBufferSize}
+	for _, opt := range opts {
+		if err := opt(&settings); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &eventSubscriber[T]{
+		ctx:    ctx,
+		ch:     make(chan Event[T], settings.bufferSize),
+		filter: filter,
+		policy: settings.policy,
+	}
+
+	c.subs.mutex.Lock()
+	if c.subs.subscribers == nil {
+		c.subs.subscribers = map[*eventSubscriber[T]]struct{}{}
+	}
+	c.subs.subscribers[sub] = struct{}{}
+	c.subs.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		c.subs.mutex.Lock()
+		delete(c.subs.subscribers, sub)
+		c.subs.mutex.Unlock()
+
+		//отправитель, ожидающий освобождения буфера, также завершается по контексту
+		sub.mutex.Lock()
+		sub.closed = true
+		close(sub.ch)
+		sub.mutex.Unlock()
+	}()
+
+	return sub.ch, nil
+}
+
+// WithSubscriberBuffer размер буфера событий подписчика, по умолчанию 64
+func WithSubscriberBuffer(size int) SubscribeOption {
+	return func(s *subscriptionSettings) error {
+		if size < 1 {
+			return errors.New("the subscriber buffer size must be greater than 0")
+		}
+
+		s.bufferSize = size
+
+		return nil
+	}
+}
+
+// WithSubscriberPolicy политика доставки событий при заполненном буфере подписчика,
+// по умолчанию DeliveryDrop
+func WithSubscriberPolicy(policy DeliveryPolicy) SubscribeOption {
+	return func(s *subscriptionSettings) error {
+		if policy != DeliveryDrop && policy != DeliveryBlock {
+			return errors.New("unknown event delivery policy")
+		}
+
+		s.policy = policy
+
+		return nil
+	}
+}
+
+// FilterByType фильтр событий для Subscribe, пропускающий события заданных типов
+func FilterByType[T any](types ...EventType) func(Event[T]) bool {
+	return func(e Event[T]) bool {
+		return slices.Contains(types, e.Type)
+	}
+}
+
+// emit передаёт события функциям обратного вызова и подписчикам. Вызывается после
+// снятия блокировок очереди и кэша, поэтому функции обратного вызова могут обращаться
+// к хранилищу
+func (c *CacheStorageWithQueue[T]) emit(events ...Event[T]) {
+	for _, event := range events {
+		//пустое событие означает, что изменения состояния объекта не было
+		if event.Type == "" {
+			continue
+		}
+
+		c.fireHooks(event)
+		c.publish(event)
+	}
+}
+
+// publish передаёт событие подписчикам
+func (c *CacheStorageWithQueue[T]) publish(event Event[T]) {
+	c.subs.mutex.RLock()
+	subscribers := make([]*eventSubscriber[T], 0, len(c.subs.subscribers))
+	for sub := range c.subs.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	c.subs.mutex.RUnlock()
+
+	for _, sub := range subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+
+		if !sub.send(event) {
+			c.stats.eventsDropped.Add(1)
+		}
+	}
+}
+
+// send передаёт событие подписчику в соответствии с политикой доставки, возвращает
+// false если событие было отброшено
+func (sub *eventSubscriber[T]) send(event Event[T]) bool {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+
+	if sub.closed {
+		return true
+	}
+
+	if sub.policy == DeliveryBlock {
+		select {
+		case sub.ch <- event:
+			return true
+
+		case <-sub.ctx.Done():
+			return true
+		}
+	}
+
+	select {
+	case sub.ch <- event:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// newEvent событие жизненного цикла объекта
+func newEvent[T any](eventType EventType, id string, obj T, reason Reason) Event[T] {
+	return Event[T]{
+		Time:   time.Now(),
+		Type:   eventType,
+		ID:     id,
+		Object: obj,
+		Reason: reason,
+	}
+}
@@ -2,9 +2,8 @@ package cachingstoragewithqueue
 
 import (
 	"context"
-	"fmt"
-
-	"github.com/av-belyakov/cachingstoragewithqueue/internal/supportingfunctions"
+	"log/slog"
+	"slices"
 )
 
 // syncExecution выполняет синхронную обработку функций из кэша
@@ -20,9 +19,9 @@ func (c *CacheStorageWithQueue[T]) syncExecution(ctx context.Context) {
 
 	currentObject, isEmpty := c.PullObjectFromQueue()
 	// если очередь с объектами для обработки не пуста и есть место в кеше
-	if !isEmpty && c.GetCacheSize() < c.cache.maxSize {
+	if !isEmpty && c.GetCacheSize() < c.cache.getMaxSize() {
 		if err := c.AddObjectToCache(currentObject.GetID(), currentObject); err != nil {
-			c.logging.Write("warning", supportingfunctions.CustomError(fmt.Errorf("cachingstoragewithqueue package: '%s'", err.Error())).Error())
+			c.log(slog.LevelWarn, logEventAdmissionRejected, slog.String("id", currentObject.GetID()), slog.Any("error", err))
 
 			return
 		}
@@ -34,21 +33,24 @@ func (c *CacheStorageWithQueue[T]) syncExecution(ctx context.Context) {
 	}
 
 	//получаем самую старую функцию, которая не выполняется или не была выполнена успешно
-	index, f := c.GetFuncFromCacheMinTimeExpiry()
+	index, _ := c.GetFuncFromCacheMinTimeExpiry()
 	if index == "" {
 		return
 	}
 
 	//меняем статус выполнения функции на 'функция в обработке',
 	// увеличиваем кол-во попыток обработки функции на 1
-	c.ChangeExecution(index)
+	f, generation, isExist := c.startExecution(index)
+	if !isExist {
+		return
+	}
 
 	//выполняем функцию и изменяем состояние задачи
-	status := f(0)
+	status := c.executeFunc(index, f)
 
 	//меняется 'execution' на false, а успешность выполнения
 	//задачи на значение полученное от функции
-	c.ChangeValues(index, status)
+	c.completeExecution(index, generation, status, nil)
 }
 
 // asyncExecution выполняет асинхронную обработку функций из кэша
@@ -61,16 +63,17 @@ func (c *CacheStorageWithQueue[T]) asyncExecution(ctx context.Context) {
 
 	//проверяем, количество выполняемых функций соответствует максимальному количеству
 	// одновременно выполняемых задач (параметр задаётся в опциях)
-	if len(listIndexes) >= c.isAsync {
+	isAsync := c.getConcurrency()
+	if len(listIndexes) >= isAsync {
 		return
 	}
 
-	count := c.isAsync - len(listIndexes)
+	count := isAsync - len(listIndexes)
 	pushObjectToCache := func(count int) []string {
 		indexes := make([]string, 0, count)
 
 		for range count {
-			if c.GetCacheSize() >= c.cache.maxSize {
+			if c.GetCacheSize() >= c.cache.getMaxSize() {
 				return indexes
 			}
 
@@ -80,7 +83,7 @@ func (c *CacheStorageWithQueue[T]) asyncExecution(ctx context.Context) {
 			}
 
 			if err := c.AddObjectToCache(object.GetID(), object); err != nil {
-				c.logging.Write("warning", supportingfunctions.CustomError(fmt.Errorf("cachingstoragewithqueue package: '%s'", err.Error())).Error())
+				c.log(slog.LevelWarn, logEventAdmissionRejected, slog.String("id", object.GetID()), slog.Any("error", err))
 			} else {
 				indexes = append(indexes, object.GetID())
 			}
@@ -91,26 +94,51 @@ func (c *CacheStorageWithQueue[T]) asyncExecution(ctx context.Context) {
 
 	//список индексов объекты которых были добавлены в кэш
 	indexes := pushObjectToCache(count)
+	//объекты, выполнение которых запрошено методом RetryNow, и периодические объекты,
+	//очередной запуск которых начат
+	for _, index := range c.readyPriority(count - len(indexes)) {
+		if !slices.Contains(indexes, index) {
+			indexes = append(indexes, index)
+		}
+	}
 	if len(indexes) == 0 {
 		return
 	}
 
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
-
 	for _, index := range indexes {
-		f, isExist := c.getFuncFromCacheByKey(index)
+		//функция для данного объекта выполняется, количество попыток выполнения функции
+		//увеличивается, блокировка сегмента кэша удерживается только на время изменения
+		//параметров объекта, но не на время запуска функции
+		f, generation, isExist := c.startExecution(index)
 		if !isExist {
 			continue
 		}
 
-		//функция для данного объекта выполняется
-		c.setIsExecutionTrue(index)
-		// увеличиваем количество попыток выполнения функции
-		c.increaseNumberExecutionAttempts(index)
-
 		go func(ind string) {
-			c.ChangeValues(ind, f(0))
+			c.completeExecution(ind, generation, c.executeFunc(ind, f), nil)
 		}(index)
 	}
 }
+
+// readyPriority возвращает не более count ключей объектов ожидающих выполнения с нулевым
+// временем в индексе ready, то есть объектов, выполнение которых запрошено методом RetryNow,
+// и периодических объектов, очередной запуск которых начат
+func (c *CacheStorageWithQueue[T]) readyPriority(count int) []string {
+	var keys []string
+
+	for _, sh := range c.cache.shards {
+		sh.mutex.RLock()
+		for key, item := range sh.ready.items {
+			if len(keys) >= count {
+				break
+			}
+
+			if item.timeExpiry.IsZero() {
+				keys = append(keys, key)
+			}
+		}
+		sh.mutex.RUnlock()
+	}
+
+	return keys
+}
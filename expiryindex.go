@@ -0,0 +1,169 @@
+package cachingstoragewithqueue
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryItem элемент индекса, ключ объекта в кэше и время истечения его жизни
+type expiryItem struct {
+	key        string
+	timeExpiry time.Time
+	//порядковый номер добавления, при одинаковом timeExpiry более старым
+	//считается объект добавленный раньше
+	seq uint64
+	//позиция элемента в куче
+	index int
+}
+
+// expiryHeap минимальная куча упорядоченная по timeExpiry, реализует heap.Interface
+type expiryHeap []*expiryItem
+
+func (h expiryHeap) Len() int { return len(h) }
+
+func (h expiryHeap) Less(i, j int) bool {
+	if h[i].timeExpiry.Equal(h[j].timeExpiry) {
+		return h[i].seq < h[j].seq
+	}
+
+	return h[i].timeExpiry.Before(h[j].timeExpiry)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	item := x.(*expiryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+
+	return item
+}
+
+// candidateHeap минимальная куча позиций элементов expiryHeap, реализует heap.Interface,
+// используется для выборки наименьших элементов без изменения expiryHeap
+type candidateHeap struct {
+	items     expiryHeap
+	positions []int
+}
+
+func (h candidateHeap) Len() int { return len(h.positions) }
+
+func (h candidateHeap) Less(i, j int) bool {
+	return h.items.Less(h.positions[i], h.positions[j])
+}
+
+func (h candidateHeap) Swap(i, j int) {
+	h.positions[i], h.positions[j] = h.positions[j], h.positions[i]
+}
+
+func (h *candidateHeap) Push(x any) {
+	h.positions = append(h.positions, x.(int))
+}
+
+func (h *candidateHeap) Pop() any {
+	n := len(h.positions)
+	position := h.positions[n-1]
+	h.positions = h.positions[:n-1]
+
+	return position
+}
+
+// expiryIndex индексированная минимальная куча ключей кэша, позволяет за O(log n)
+// добавлять, изменять и удалять ключи и за O(1) получать ключ с наименьшим timeExpiry
+type expiryIndex struct {
+	heap  expiryHeap
+	items map[string]*expiryItem
+	seq   uint64
+}
+
+// newExpiryIndex новый индекс
+func newExpiryIndex() *expiryIndex {
+	return &expiryIndex{items: map[string]*expiryItem{}}
+}
+
+// set добавляет ключ в индекс или обновляет время истечения жизни уже существующего ключа
+func (ei *expiryIndex) set(key string, timeExpiry time.Time) {
+	ei.seq++
+
+	if item, ok := ei.items[key]; ok {
+		item.timeExpiry = timeExpiry
+		item.seq = ei.seq
+		heap.Fix(&ei.heap, item.index)
+
+		return
+	}
+
+	item := &expiryItem{key: key, timeExpiry: timeExpiry, seq: ei.seq}
+	ei.items[key] = item
+	heap.Push(&ei.heap, item)
+}
+
+// remove удаляет ключ из индекса
+func (ei *expiryIndex) remove(key string) {
+	item, ok := ei.items[key]
+	if !ok {
+		return
+	}
+
+	heap.Remove(&ei.heap, item.index)
+	delete(ei.items, key)
+}
+
+// peek возвращает ключ с наименьшим timeExpiry, без его удаления из индекса
+func (ei *expiryIndex) peek() (key string, timeExpiry time.Time, ok bool) {
+	if len(ei.heap) == 0 {
+		return "", time.Time{}, false
+	}
+
+	return ei.heap[0].key, ei.heap[0].timeExpiry, true
+}
+
+// smallest возвращает не более n элементов с наименьшим timeExpiry, в порядке возрастания,
+// без изменения индекса. Элемент кучи может попасть в результат только после своего
+// родителя, поэтому просматриваются только потомки уже выбранных элементов и сложность
+// составляет O(n log n) независимо от количества ключей в индексе
+func (ei *expiryIndex) smallest(n int) []*expiryItem {
+	list := make([]*expiryItem, 0, min(n, len(ei.heap)))
+	if len(ei.heap) == 0 {
+		return list
+	}
+
+	candidates := &candidateHeap{items: ei.heap, positions: []int{0}}
+	for len(list) < n && candidates.Len() > 0 {
+		position := heap.Pop(candidates).(int)
+		list = append(list, ei.heap[position])
+
+		//потомки элемента в куче пакета container/heap
+		for _, child := range []int{2*position + 1, 2*position + 2} {
+			if child < len(ei.heap) {
+				heap.Push(candidates, child)
+			}
+		}
+	}
+
+	return list
+}
+
+// len количество ключей в индексе
+func (ei *expiryIndex) len() int {
+	return len(ei.heap)
+}
+
+// clean очистка индекса
+func (ei *expiryIndex) clean() {
+	ei.heap = expiryHeap(nil)
+	ei.items = map[string]*expiryItem{}
+}
@@ -0,0 +1,75 @@
+package cachingstoragewithqueue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// GetOrLoad возвращает объект из кэша по ключу, а если объекта в кэше нет, получает его
+// с помощью пользовательской функции loader и добавляет в кэш. Одновременные вызовы
+// с одинаковым ключом разделяют одну загрузку, функция loader вызывается один раз, с
+// контекстом первого из вызывающих. Загруженный объект добавляется в кэш как успешно
+// выполненный, поэтому его функция не выполняется, а сам объект удаляется из кэша по
+// общим правилам, по истечении времени жизни или при превышении размера кэша. Если кэш
+// заполнен и самый старый объект удалить нельзя, загруженный объект возвращается без
+// добавления в кэш
+func (c *CacheStorageWithQueue[T]) GetOrLoad(ctx context.Context, key string, loader func(context.Context) (T, error)) (T, error) {
+	if obj, ok := c.GetObjectFromCacheByKey(key); ok {
+		return obj, nil
+	}
+
+	obj, err, _ := c.loads.Do(ctx, key, func() (T, error) {
+		//пока ожидали загрузку объект мог быть добавлен в кэш
+		if obj, ok := c.GetObjectFromCacheByKey(key); ok {
+			return obj, nil
+		}
+
+		obj, err := loader(ctx)
+		if err != nil {
+			return obj, err
+		}
+
+		return c.addLoadedObjectToCache(key, obj), nil
+	})
+
+	return obj, err
+}
+
+// addLoadedObjectToCache добавляет в кэш загруженный объект, если объекта с таким ключом
+// в кэше ещё нет, и возвращает объект находящийся в кэше
+func (c *CacheStorageWithQueue[T]) addLoadedObjectToCache(key string, obj T) T {
+	//при достижении максимального размера кэша удаляется самый старый объект
+	if c.GetCacheSize() >= c.cache.getMaxSize() {
+		if err := c.DeleteOldestObjectFromCache(); err != nil {
+			c.log(slog.LevelWarn, logEventAdmissionRejected, slog.String("id", key), slog.Any("error", err))
+
+			return obj
+		}
+	}
+
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	if storage, ok := sh.storages.get(key); ok {
+		sh.mutex.Unlock()
+
+		return storage.originalObject
+	}
+
+	storage := storageParameters[T]{
+		timeMain:       time.Now(),
+		timeExpiry:     time.Now().Add(c.getMaxTtl()),
+		originalObject: obj,
+		//у загруженного объекта нет пользовательской функции обработки
+		cacheFunc:               func(int) bool { return true },
+		isCompletedSuccessfully: true,
+	}
+	sh.set(key, storage)
+	c.stats.admitted.Add(1)
+	c.walStore(walOpState, key, storage)
+	sh.mutex.Unlock()
+
+	c.emit(newEvent(EventAdmitted, key, obj, ReasonNew))
+
+	return obj
+}
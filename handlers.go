@@ -0,0 +1,77 @@
+package cachingstoragewithqueue
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// RegisterHandler регистрирует обработчик объектов под именем name. Объекты, вспомогательный
+// тип которых реализует интерфейс NamedHandler, выполняются зарегистрированным под этим
+// именем обработчиком, который определяется в момент выполнения, поэтому обработчик может
+// быть зарегистрирован и после добавления объекта в очередь
+func (c *CacheStorageWithQueue[T]) RegisterHandler(name string, handler HandlerFunc[T]) error {
+	if name == "" {
+		return errors.New("the handler name is not set")
+	}
+
+	if handler == nil {
+		return fmt.Errorf("the handler with the name '%s' is not set", name)
+	}
+
+	c.handlers.mutex.Lock()
+	defer c.handlers.mutex.Unlock()
+
+	if _, ok := c.handlers.handlers[name]; ok {
+		return fmt.Errorf("the handler with the name '%s' is already registered", name)
+	}
+
+	if c.handlers.handlers == nil {
+		c.handlers.handlers = map[string]HandlerFunc[T]{}
+	}
+	c.handlers.handlers[name] = handler
+
+	return nil
+}
+
+// getHandler возвращает зарегистрированный обработчик по имени
+func (c *CacheStorageWithQueue[T]) getHandler(name string) (HandlerFunc[T], bool) {
+	c.handlers.mutex.RLock()
+	defer c.handlers.mutex.RUnlock()
+
+	handler, ok := c.handlers.handlers[name]
+
+	return handler, ok
+}
+
+// getFunc возвращает функцию-обёртку выполнения объекта, для объекта с именем обработчика
+// это зарегистрированный под этим именем обработчик, иначе функция заданная через SetFunc
+func (c *CacheStorageWithQueue[T]) getFunc(key string, storage storageParameters[T]) func(int) bool {
+	if storage.handlerName == "" {
+		return storage.cacheFunc
+	}
+
+	handler, ok := c.getHandler(storage.handlerName)
+	if !ok {
+		return func(int) bool {
+			c.logError(logEventHandlerNotRegistered, fmt.Errorf("the handler with the name '%s' is not registered", storage.handlerName), slog.String("id", key))
+
+			return false
+		}
+	}
+
+	obj := storage.originalObject
+
+	return func(int) bool {
+		return handler(key, obj)
+	}
+}
+
+// getHandlerName возвращает имя обработчика если вспомогательный тип реализует интерфейс NamedHandler
+func getHandlerName[T any](value CacheStorageHandler[T]) string {
+	if nh, ok := value.(NamedHandler); ok {
+		return nh.GetHandlerName()
+	}
+
+	return ""
+}
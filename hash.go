@@ -0,0 +1,31 @@
+package cachingstoragewithqueue
+
+import "bytes"
+
+// compareHashes сравнивает отпечатки объекта из кэша и нового объекта, возвращает
+// идентичны ли объекты и удалось ли выполнить сравнение, сравнение невозможно если
+// хотя бы один из отпечатков неизвестен
+func (c *CacheStorageWithQueue[T]) compareHashes(hashFromCache, hash []byte) (isIdentical bool, ok bool) {
+	if hashFromCache == nil || hash == nil {
+		return false, false
+	}
+
+	if bytes.Equal(hashFromCache, hash) {
+		c.stats.hashDuplicates.Add(1)
+
+		return true, true
+	}
+
+	c.stats.hashComparisonsSkipped.Add(1)
+
+	return false, true
+}
+
+// getHash возвращает отпечаток объекта если вспомогательный тип реализует интерфейс Hasher
+func getHash[T any](value CacheStorageHandler[T]) []byte {
+	if h, ok := value.(Hasher); ok {
+		return h.Hash()
+	}
+
+	return nil
+}
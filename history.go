@@ -0,0 +1,74 @@
+package cachingstoragewithqueue
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// GetObjectHistory возвращает предыдущие версии объекта с заданным ключом, от более
+// старой к более новой, и найден ли объект в кэше. Текущая версия объекта в список
+// не входит. Версии хранятся только если задана опция WithObjectHistory
+func (c *CacheStorageWithQueue[T]) GetObjectHistory(key string) ([]ObjectVersion[T], bool) {
+	storage, ok := c.getStorageParameters(key)
+	if !ok {
+		return nil, false
+	}
+
+	return slices.Clone(storage.history), true
+}
+
+// push возвращает новый список версий с добавленной версией объекта, при превышении
+// заданного количества версий самая старая версия удаляется. Исходный список не
+// изменяется, так как он может использоваться копиями параметров объекта, полученными
+// без блокировки сегмента кэша
+func (ho historyOptions[T]) push(history []ObjectVersion[T], obj T, t time.Time) []ObjectVersion[T] {
+	if ho.size == 0 {
+		return history
+	}
+
+	if ho.clone != nil {
+		obj = ho.clone(obj)
+	}
+
+	if len(history) >= ho.size {
+		history = history[len(history)-ho.size+1:]
+	}
+
+	list := make([]ObjectVersion[T], len(history), len(history)+1)
+	copy(list, history)
+
+	return append(list, ObjectVersion[T]{Object: obj, Time: t})
+}
+
+// encodeHistory кодирует предыдущие версии объекта
+func encodeHistory[T any](codec Codec[T], key string, history []ObjectVersion[T]) ([]snapshotVersionItem, error) {
+	var list []snapshotVersionItem
+
+	for _, v := range history {
+		b, err := codec.Encode(v.Object)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding a version of the cache object with key ID '%s': %w", key, err)
+		}
+
+		list = append(list, snapshotVersionItem{Time: v.Time, Object: b})
+	}
+
+	return list, nil
+}
+
+// decodeHistory декодирует предыдущие версии объекта
+func decodeHistory[T any](codec Codec[T], key string, list []snapshotVersionItem) ([]ObjectVersion[T], error) {
+	var history []ObjectVersion[T]
+
+	for _, v := range list {
+		obj, err := codec.Decode(v.Object)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding a version of the cache object with key ID '%s': %w", key, err)
+		}
+
+		history = append(history, ObjectVersion[T]{Object: obj, Time: v.Time})
+	}
+
+	return history, nil
+}
@@ -0,0 +1,68 @@
+package cachingstoragewithqueue
+
+// OnAdmit регистрирует функцию обратного вызова, вызываемую при добавлении нового
+// объекта в кэш, причина ReasonNew
+func (c *CacheStorageWithQueue[T]) OnAdmit(f HookFunc[T]) {
+	c.addHook(f, EventAdmitted)
+}
+
+// OnDuplicate регистрирует функцию обратного вызова, вызываемую при отклонении объекта
+// идентичного объекту в кэше или уже успешно выполненного ранее, причины ReasonIdentical,
+// ReasonIdenticalHash и ReasonAlreadyProcessed
+func (c *CacheStorageWithQueue[T]) OnDuplicate(f HookFunc[T]) {
+	c.addHook(f, EventDuplicate)
+}
+
+// OnReplace регистрирует функцию обратного вызова, вызываемую при замене объекта в кэше
+// результатом MatchingAndReplacement, причина ReasonReplaced
+func (c *CacheStorageWithQueue[T]) OnReplace(f HookFunc[T]) {
+	c.addHook(f, EventReplaced)
+}
+
+// OnSuccess регистрирует функцию обратного вызова, вызываемую при успешном выполнении
+// функции объекта, причина ReasonSucceeded
+func (c *CacheStorageWithQueue[T]) OnSuccess(f HookFunc[T]) {
+	c.addHook(f, EventSucceeded)
+}
+
+// OnFailure регистрирует функцию обратного вызова, вызываемую при неуспешном выполнении
+// функции объекта, причина ReasonFailed
+func (c *CacheStorageWithQueue[T]) OnFailure(f HookFunc[T]) {
+	c.addHook(f, EventFailed)
+}
+
+// OnEvict регистрирует функцию обратного вызова, вызываемую при вытеснении объекта из
+// кэша по истечении времени жизни или при превышении размера кэша, причины ReasonTtl
+// и ReasonSize
+func (c *CacheStorageWithQueue[T]) OnEvict(f HookFunc[T]) {
+	c.addHook(f, EventEvicted, EventExpired)
+}
+
+// addHook добавляет функцию обратного вызова для событий заданных типов, nil игнорируется
+func (c *CacheStorageWithQueue[T]) addHook(f HookFunc[T], types ...EventType) {
+	if f == nil {
+		return
+	}
+
+	c.hooks.mutex.Lock()
+	defer c.hooks.mutex.Unlock()
+
+	if c.hooks.hooks == nil {
+		c.hooks.hooks = map[EventType][]HookFunc[T]{}
+	}
+
+	for _, t := range types {
+		c.hooks.hooks[t] = append(c.hooks.hooks[t], f)
+	}
+}
+
+// fireHooks вызывает функции обратного вызова, зарегистрированные для типа события
+func (c *CacheStorageWithQueue[T]) fireHooks(event Event[T]) {
+	c.hooks.mutex.RLock()
+	hooks := c.hooks.hooks[event.Type]
+	c.hooks.mutex.RUnlock()
+
+	for _, f := range hooks {
+		f(event.ID, event.Object, event.Reason)
+	}
+}
@@ -1,5 +1,10 @@
 package cachingstoragewithqueue
 
+import (
+	"context"
+	"time"
+)
+
 type CacheStorageHandler[T any] interface {
 	CacheStorageGetter[T]
 	CacheStorageSetter[T]
@@ -19,6 +24,55 @@ type CacheStorageSetter[T any] interface {
 	SetObject(T)
 }
 
+// Hasher необязательный интерфейс, который может реализовывать вспомогательный тип
+// CacheStorageHandler. Hash возвращает отпечаток объекта, например 64-битный хеш или
+// SHA-256, одинаковый для объектов которые Comparison считает идентичными. Если отпечатки
+// объекта в кэше и нового объекта совпадают, объекты считаются идентичными, а если
+// различаются, объект в кэше заменяется без вызова Comparison
+type Hasher interface {
+	Hash() []byte
+}
+
+// NamedHandler необязательный интерфейс, который может реализовывать вспомогательный тип
+// CacheStorageHandler. Если GetHandlerName возвращает не пустое имя, объект выполняется
+// обработчиком зарегистрированным под этим именем методом RegisterHandler, а функция
+// заданная через SetFunc не используется
+type NamedHandler interface {
+	GetHandlerName() string
+}
+
+// ContextHandler необязательный интерфейс, который может реализовывать вспомогательный тип
+// CacheStorageHandler. Если GetContextFunc возвращает не nil, объект выполняется этой функцией
+// вместо функции заданной через SetFunc. Функция принимает контекст выполнения, который
+// отменяется при отмене выполнения объекта методом Cancel
+type ContextHandler interface {
+	GetContextFunc() func(ctx context.Context) bool
+}
+
+// ResultHandler необязательный интерфейс, который может реализовывать вспомогательный тип
+// CacheStorageHandler. Если GetResultFunc возвращает не nil, объект выполняется этой функцией
+// вместо функции заданной через SetFunc и вместо функции интерфейса ContextHandler. Функция
+// принимает контекст выполнения, аналогично ContextHandler, и возвращает, кроме успешности
+// выполнения, результат, который сохраняется в кэше вместе с объектом и доступен через
+// GetResult, GetEntry и события жизненного цикла
+type ResultHandler interface {
+	GetResultFunc() func(ctx context.Context) (any, bool)
+}
+
+// DelayedHandler необязательный интерфейс, который может реализовывать вспомогательный тип
+// CacheStorageHandler. Если NotBefore возвращает время в будущем, объект добавленный методом
+// PushObjectToQueue ожидает в очереди и не выполняется раньше этого времени
+type DelayedHandler interface {
+	NotBefore() time.Time
+}
+
+// Codec кодирование и декодирование объектов типа T, используется при сохранении
+// состояния хранилища
+type Codec[T any] interface {
+	Encode(T) ([]byte, error)
+	Decode([]byte) (T, error)
+}
+
 type WriterLoggingData interface {
 	Write(msgType, msg string) bool
 }
@@ -0,0 +1,90 @@
+package bloomfilter
+
+import "math"
+
+const (
+	//параметры хеш-функции FNV-1a
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// BloomFilter фильтр Блума, вероятностная структура позволяющая проверить принадлежность
+// элемента множеству, ложноотрицательные ответы невозможны, ложноположительные возможны
+// с заданной вероятностью
+type BloomFilter struct {
+	bits  []uint64
+	size  uint64
+	count uint64
+	k     uint64
+}
+
+// New создает фильтр Блума рассчитанный на expectedItems элементов с вероятностью
+// ложноположительного ответа falsePositiveRate
+func New(expectedItems int, falsePositiveRate float64) *BloomFilter {
+	n := math.Max(float64(expectedItems), 1)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(math.Round(m/n*math.Ln2), 1)
+
+	size := uint64(m)
+
+	return &BloomFilter{
+		bits: make([]uint64, (size+63)/64),
+		size: size,
+		k:    uint64(k),
+	}
+}
+
+// Add добавляет элемент в фильтр
+func (bf *BloomFilter) Add(v string) {
+	h1, h2 := hashes(v)
+	for i := range bf.k {
+		pos := (h1 + i*h2) % bf.size
+		bf.bits[pos/64] |= 1 << (pos % 64)
+	}
+
+	bf.count++
+}
+
+// Contains проверяет, возможно ли элемент был добавлен в фильтр
+func (bf *BloomFilter) Contains(v string) bool {
+	h1, h2 := hashes(v)
+	for i := range bf.k {
+		pos := (h1 + i*h2) % bf.size
+		if bf.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Count количество добавленных элементов
+func (bf *BloomFilter) Count() uint64 {
+	return bf.count
+}
+
+// Reset очищает фильтр
+func (bf *BloomFilter) Reset() {
+	clear(bf.bits)
+	bf.count = 0
+}
+
+// hashes два независимых хеша для двойного хеширования, вычисляются из FNV-1a
+func hashes(v string) (uint64, uint64) {
+	var h uint64 = fnvOffset64
+	for i := 0; i < len(v); i++ {
+		h ^= uint64(v[i])
+		h *= fnvPrime64
+	}
+
+	//второй хеш получаем перемешиванием первого (финализатор splitmix64),
+	//нечётность второго хеша исключает нулевой шаг
+	h2 := h
+	h2 ^= h2 >> 30
+	h2 *= 0xbf58476d1ce4e5b9
+	h2 ^= h2 >> 27
+	h2 *= 0x94d049bb133111eb
+	h2 ^= h2 >> 31
+
+	return h, h2 | 1
+}
@@ -0,0 +1,233 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// количество лет, в пределах которых ищется время следующего запуска, если за это время
+// подходящее время не найдено (например 30 февраля), расписание считается невыполнимым
+const searchYears = 5
+
+// Schedule расписание периодического запуска, интервал или выражение cron из пяти
+// полей: минуты, часы, день месяца, месяц, день недели
+type Schedule struct {
+	spec  string
+	every time.Duration
+	//допустимые значения полей выражения cron, бит с номером значения
+	minute, hour, dom, month, dow uint64
+	//день месяца и день недели не ограничены ('*'), если оба поля ограничены
+	//подходит день удовлетворяющий любому из них
+	domStar, dowStar bool
+}
+
+// field диапазон допустимых значений поля выражения cron
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// сокращенные выражения cron
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Every создает расписание запуска через равные интервалы времени
+func Every(d time.Duration) (*Schedule, error) {
+	if d < time.Second {
+		return nil, errors.New("the interval of the schedule should not be less than 1 second")
+	}
+
+	return &Schedule{spec: "@every " + d.String(), every: d}, nil
+}
+
+// Parse разбирает расписание, выражение cron из пяти полей, сокращенное выражение
+// (@hourly, @daily, @weekly, @monthly, @yearly) или интервал вида '@every 1h30m'
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if v, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval of the schedule '%s': %w", spec, err)
+		}
+
+		return Every(d)
+	}
+
+	expr := spec
+	if v, ok := descriptors[spec]; ok {
+		expr = v
+	}
+
+	list := strings.Fields(expr)
+	if len(list) != len(fields) {
+		return nil, fmt.Errorf("the cron expression '%s' must contain %d fields", spec, len(fields))
+	}
+
+	values := make([]uint64, len(fields))
+	for i, f := range fields {
+		v, err := parseField(list[i], f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %w", spec, err)
+		}
+
+		values[i] = v
+	}
+
+	s := &Schedule{
+		spec:    spec,
+		minute:  values[0],
+		hour:    values[1],
+		dom:     values[2],
+		month:   values[3],
+		dow:     values[4],
+		domStar: list[2] == "*",
+		dowStar: list[4] == "*",
+	}
+
+	//воскресенье может быть задано как 0 или 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+// parseField разбирает поле выражения cron, список значений, диапазонов и шагов
+func parseField(v string, f field) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(v, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step '%s' of the %s field", stepStr, f.name)
+			}
+			step = n
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case rng == "*":
+
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			from, errFrom := strconv.Atoi(a)
+			to, errTo := strconv.Atoi(b)
+			if errFrom != nil || errTo != nil || from > to {
+				return 0, fmt.Errorf("invalid range '%s' of the %s field", rng, f.name)
+			}
+			start, end = from, to
+
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value '%s' of the %s field", rng, f.name)
+			}
+			start = n
+			if !hasStep {
+				end = n
+			}
+		}
+
+		if start < f.min || end > f.max {
+			return 0, fmt.Errorf("the value of the %s field must be between %d and %d", f.name, f.min, f.max)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << i
+		}
+	}
+
+	return bits, nil
+}
+
+// String исходное представление расписания, для nil пустая строка
+func (s *Schedule) String() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.spec
+}
+
+// Next возвращает ближайшее время запуска после t, для выражения cron время
+// вычисляется с точностью до минуты в часовом поясе t, нулевое время означает,
+// что подходящее время запуска не найдено
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+WRAP:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t
+}
+
+// dayMatches проверяет соответствие дня месяца и дня недели расписанию
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
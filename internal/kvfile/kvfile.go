@@ -0,0 +1,203 @@
+package kvfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	//минимальный объем неиспользуемых данных в файле, при котором выполняется сжатие
+	minGarbageSize = 1 << 20
+)
+
+// location расположение значения в файле
+type location struct {
+	offset int64
+	size   int
+}
+
+// Store встраиваемое хранилище ключ-значение, значения которого хранятся в файле,
+// а в памяти находится только индекс ключей. Значения дописываются в конец файла,
+// при замене или удалении значения занимаемое им место становится неиспользуемым
+// и освобождается при сжатии файла, которое выполняется автоматически. Хранилище
+// является временным и предназначено только для вытеснения значений из памяти, индекс
+// ключей в файл не записывается, поэтому при открытии файл очищается, а при закрытии
+// удаляется, значения между запусками не сохраняются.
+// Методы Get, Len и Keys могут вызываться одновременно, остальные методы требуют
+// исключительного доступа
+type Store struct {
+	file  *os.File
+	path  string
+	index map[string]location
+	//размер файла
+	size int64
+	//объем неиспользуемых данных в файле
+	garbage int64
+}
+
+// Open создает или очищает файл хранилища path, ранее записанные в файл значения
+// не восстанавливаются
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		file:  f,
+		path:  path,
+		index: map[string]location{},
+	}, nil
+}
+
+// Get возвращает значение по ключу
+func (s *Store) Get(key string) ([]byte, bool, error) {
+	loc, ok := s.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	value := make([]byte, loc.size)
+	if _, err := s.file.ReadAt(value, loc.offset); err != nil {
+		return nil, false, fmt.Errorf("error reading the value of key '%s': %w", key, err)
+	}
+
+	return value, true, nil
+}
+
+// Put добавляет или заменяет значение по ключу
+func (s *Store) Put(key string, value []byte) error {
+	if s.file == nil {
+		return errors.New("the key-value store is closed")
+	}
+
+	if _, err := s.file.WriteAt(value, s.size); err != nil {
+		return fmt.Errorf("error writing the value of key '%s': %w", key, err)
+	}
+
+	if loc, ok := s.index[key]; ok {
+		s.garbage += int64(loc.size)
+	}
+	s.index[key] = location{offset: s.size, size: len(value)}
+	s.size += int64(len(value))
+
+	return s.compactIfNeeded()
+}
+
+// Delete удаляет значение по ключу
+func (s *Store) Delete(key string) error {
+	loc, ok := s.index[key]
+	if !ok {
+		return nil
+	}
+
+	delete(s.index, key)
+	s.garbage += int64(loc.size)
+
+	return s.compactIfNeeded()
+}
+
+// Len количество ключей в хранилище
+func (s *Store) Len() int {
+	return len(s.index)
+}
+
+// Keys ключи хранилища, в произвольном порядке
+func (s *Store) Keys() []string {
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Clear удаляет все значения из хранилища
+func (s *Store) Clear() error {
+	if s.file == nil {
+		return errors.New("the key-value store is closed")
+	}
+
+	if err := s.file.Truncate(0); err != nil {
+		return err
+	}
+
+	s.index = map[string]location{}
+	s.size = 0
+	s.garbage = 0
+
+	return nil
+}
+
+// Close закрывает и удаляет файл хранилища
+func (s *Store) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	if errRemove := os.Remove(s.path); err == nil {
+		err = errRemove
+	}
+
+	return err
+}
+
+// compactIfNeeded сжимает файл, если неиспользуемые данные занимают больше его половины
+func (s *Store) compactIfNeeded() error {
+	if s.garbage < minGarbageSize || s.garbage*2 < s.size {
+		return nil
+	}
+
+	return s.compact()
+}
+
+// compact переписывает используемые значения в новый файл, который заменяет текущий
+func (s *Store) compact() error {
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	index := make(map[string]location, len(s.index))
+	var size int64
+	for key := range s.index {
+		value, _, err := s.Get(key)
+		if err != nil {
+			f.Close()
+
+			return err
+		}
+
+		if _, err := f.WriteAt(value, size); err != nil {
+			f.Close()
+
+			return err
+		}
+
+		index[key] = location{offset: size, size: len(value)}
+		size += int64(len(value))
+	}
+
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	s.file.Close()
+	s.file = f
+	s.index = index
+	s.size = size
+	s.garbage = 0
+
+	return nil
+}
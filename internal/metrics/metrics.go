@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ContentType тип содержимого текстового формата метрик Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Label метка метрики
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Histogram гистограмма распределения наблюдаемых значений с фиксированными
+// верхними границами интервалов, безопасна для одновременного использования
+type Histogram struct {
+	bounds []float64
+	//количество значений в каждом интервале, последний интервал +Inf
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	//сумма значений, хранится в виде битового представления float64
+	sum atomic.Uint64
+}
+
+// NewHistogram создает гистограмму с заданными верхними границами интервалов
+func NewHistogram(bounds ...float64) *Histogram {
+	b := append([]float64(nil), bounds...)
+	sort.Float64s(b)
+
+	return &Histogram{
+		bounds: b,
+		counts: make([]atomic.Uint64, len(b)+1),
+	}
+}
+
+// Observe добавляет значение в гистограмму
+func (h *Histogram) Observe(v float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, v)].Add(1)
+	h.count.Add(1)
+
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Write записывает гистограмму в текстовом формате Prometheus
+func (h *Histogram) Write(w io.Writer, name, help string, labels ...Label) {
+	writeHeader(w, name, help, "histogram")
+
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i].Load()
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatLabels(append(labels, Label{"le", formatFloat(bound)})), cumulative)
+	}
+	cumulative += h.counts[len(h.bounds)].Load()
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, formatLabels(append(labels, Label{"le", "+Inf"})), cumulative)
+
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, formatLabels(labels), formatFloat(math.Float64frombits(h.sum.Load())))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, formatLabels(labels), h.count.Load())
+}
+
+// WriteCounter записывает счетчик в текстовом формате Prometheus
+func WriteCounter(w io.Writer, name, help string, value uint64, labels ...Label) {
+	writeHeader(w, name, help, "counter")
+	fmt.Fprintf(w, "%s%s %d\n", name, formatLabels(labels), value)
+}
+
+// WriteGauge записывает текущее значение в текстовом формате Prometheus
+func WriteGauge(w io.Writer, name, help string, value float64, labels ...Label) {
+	writeHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labels), formatFloat(value))
+}
+
+// writeHeader записывает описание и тип метрики
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// formatLabels формирует список меток, значения экранируются
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	list := make([]string, 0, len(labels))
+	for _, l := range labels {
+		list = append(list, l.Name+`="`+replacer.Replace(l.Value)+`"`)
+	}
+
+	return "{" + strings.Join(list, ",") + "}"
+}
+
+// formatFloat форматирует число в текстовом формате Prometheus
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
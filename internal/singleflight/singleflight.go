@@ -0,0 +1,65 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPanic возвращается ожидающим вызовам если функция завершилась паникой
+var ErrPanic = errors.New("the function of the shared call ended with a panic")
+
+// Group группа вызовов, в которой одновременные вызовы с одинаковым ключом
+// выполняются только один раз, а результат разделяется между всеми вызывающими
+type Group[V any] struct {
+	mutex sync.Mutex
+	calls map[string]*call[V]
+}
+
+// call выполняемый или завершенный вызов
+type call[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// Do выполняет функцию fn для ключа key, если для этого ключа нет уже выполняющегося
+// вызова, иначе ожидает завершения выполняющегося вызова и возвращает его результат.
+// Ожидание прерывается при отмене контекста ctx, при этом сам вызов продолжается.
+// Значение shared равно true если результат получен от вызова инициированного
+// другим вызывающим
+func (g *Group[V]) Do(ctx context.Context, key string, fn func() (V, error)) (v V, err error, shared bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call[V]{}
+	}
+
+	if c, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return v, ctx.Err(), true
+
+		case <-c.done:
+			return c.val, c.err, true
+		}
+	}
+
+	//если fn завершится паникой ожидающие вызовы получат ErrPanic
+	c := &call[V]{done: make(chan struct{}), err: ErrPanic}
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+
+		close(c.done)
+	}()
+
+	c.val, c.err = fn()
+
+	return c.val, c.err, false
+}
@@ -0,0 +1,408 @@
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentPrefix    = "wal-"
+	segmentSuffix    = ".log"
+	checkpointSuffix = ".checkpoint"
+	//заголовок записи, длина и контрольная сумма
+	headerSize = 8
+	//максимальный размер одной записи
+	maxRecordSize = 256 << 20
+)
+
+// FsyncPolicy политика сброса записей журнала на диск
+type FsyncPolicy int
+
+const (
+	//FsyncAlways сброс на диск после каждой записи
+	FsyncAlways FsyncPolicy = iota
+	//FsyncInterval сброс на диск не чаще одного раза в заданный интервал
+	FsyncInterval
+	//FsyncNever сброс на диск выполняется операционной системой
+	FsyncNever
+)
+
+// Log журнал упреждающей записи, состоящий из сегментов и контрольных точек.
+// Сегмент содержит последовательность записей, каждая запись предваряется длиной
+// и контрольной суммой. Контрольная точка содержит полное состояние на момент
+// начала сегмента с тем же номером, после её записи более старые сегменты удаляются
+type Log struct {
+	mutex sync.Mutex
+	file  *os.File
+	dir   string
+	//время последнего сброса на диск
+	lastSync time.Time
+	//номер текущего сегмента
+	segment uint64
+	//размер текущего сегмента
+	size int64
+	//максимальный размер сегмента
+	segmentSize int64
+	//количество сегментов записанных после последней контрольной точки
+	segmentsSinceCheckpoint int
+	policy                  FsyncPolicy
+	interval                time.Duration
+}
+
+// Open открывает журнал в директории dir, записи всегда добавляются в новый сегмент,
+// что исключает дописывание к сегменту, последняя запись которого могла быть повреждена
+func Open(dir string, policy FsyncPolicy, interval time.Duration, segmentSize int64) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	segments, checkpoints, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var last uint64
+	if len(segments) > 0 {
+		last = segments[len(segments)-1]
+	}
+	if len(checkpoints) > 0 && checkpoints[len(checkpoints)-1] > last {
+		last = checkpoints[len(checkpoints)-1]
+	}
+
+	l := &Log{
+		dir:         dir,
+		policy:      policy,
+		interval:    interval,
+		segmentSize: segmentSize,
+		lastSync:    time.Now(),
+	}
+
+	for _, num := range segments {
+		if len(checkpoints) == 0 || num >= checkpoints[len(checkpoints)-1] {
+			l.segmentsSinceCheckpoint++
+		}
+	}
+
+	if err := l.openSegment(last + 1); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Append добавляет запись в журнал, при превышении размера сегмента начинается новый сегмент
+func (l *Log) Append(record []byte) error {
+	if len(record) > maxRecordSize {
+		return fmt.Errorf("the size of the write-ahead log record %d exceeds the maximum %d", len(record), maxRecordSize)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file == nil {
+		return errors.New("the write-ahead log is closed")
+	}
+
+	if l.size > 0 && l.size+int64(len(record)+headerSize) > l.segmentSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFrame(l.file, record); err != nil {
+		return err
+	}
+	l.size += int64(headerSize + len(record))
+
+	switch l.policy {
+	case FsyncAlways:
+		return l.file.Sync()
+
+	case FsyncInterval:
+		if time.Since(l.lastSync) >= l.interval {
+			l.lastSync = time.Now()
+
+			return l.file.Sync()
+		}
+	}
+
+	return nil
+}
+
+// Sync сбрасывает текущий сегмент на диск
+func (l *Log) Sync() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	l.lastSync = time.Now()
+
+	return l.file.Sync()
+}
+
+// SegmentsSinceCheckpoint количество сегментов записанных после последней контрольной точки
+func (l *Log) SegmentsSinceCheckpoint() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.segmentsSinceCheckpoint
+}
+
+// Rotate начинает новый сегмент и возвращает его номер, для последующей записи
+// контрольной точки методом WriteCheckpoint. Состояние для контрольной точки должно
+// включать все изменения, записанные в журнал до начала нового сегмента, и ни одного
+// изменения записанного после, поэтому вызывающая сторона должна исключить запись
+// в журнал между вызовом Rotate и получением состояния
+func (l *Log) Rotate() (uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file == nil {
+		return 0, errors.New("the write-ahead log is closed")
+	}
+
+	if err := l.rotate(); err != nil {
+		return 0, err
+	}
+	l.segmentsSinceCheckpoint = 1
+
+	return l.segment, nil
+}
+
+// WriteCheckpoint записывает контрольную точку с номером num, полученным от Rotate.
+// После записи контрольной точки более старые сегменты и контрольные точки удаляются
+func (l *Log) WriteCheckpoint(num uint64, records [][]byte) error {
+	f, err := os.CreateTemp(l.dir, "checkpoint-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	for _, record := range records {
+		if err := writeFrame(f, record); err != nil {
+			f.Close()
+
+			return err
+		}
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), filepath.Join(l.dir, fileName(num, checkpointSuffix))); err != nil {
+		return err
+	}
+
+	return l.removeBefore(num)
+}
+
+// Close сбрасывает текущий сегмент на диск и закрывает журнал
+func (l *Log) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Sync()
+	if errClose := l.file.Close(); err == nil {
+		err = errClose
+	}
+	l.file = nil
+
+	return err
+}
+
+// Replay последовательно передает функции f записи последней контрольной точки и всех
+// сегментов, записанных после неё. Чтение сегмента прекращается на первой поврежденной
+// или не полностью записанной записи
+func Replay(dir string, f func(record []byte) error) error {
+	segments, checkpoints, err := listFiles(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	var from uint64
+	if len(checkpoints) > 0 {
+		from = checkpoints[len(checkpoints)-1]
+		if err := replayFile(filepath.Join(dir, fileName(from, checkpointSuffix)), f); err != nil {
+			return err
+		}
+	}
+
+	for _, num := range segments {
+		if num < from {
+			continue
+		}
+
+		if err := replayFile(filepath.Join(dir, fileName(num, segmentSuffix)), f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotate закрывает текущий сегмент и начинает новый
+func (l *Log) rotate() error {
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	l.segmentsSinceCheckpoint++
+
+	return l.openSegment(l.segment + 1)
+}
+
+// openSegment создает сегмент с заданным номером
+func (l *Log) openSegment(num uint64) error {
+	f, err := os.OpenFile(filepath.Join(l.dir, fileName(num, segmentSuffix)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	l.file = f
+	l.segment = num
+	l.size = 0
+
+	return nil
+}
+
+// removeBefore удаляет сегменты и контрольные точки с номером меньше num
+func (l *Log) removeBefore(num uint64) error {
+	segments, checkpoints, err := listFiles(l.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range segments {
+		if n < num {
+			if err := os.Remove(filepath.Join(l.dir, fileName(n, segmentSuffix))); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, n := range checkpoints {
+		if n < num {
+			if err := os.Remove(filepath.Join(l.dir, fileName(n, checkpointSuffix))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFrame записывает запись с заголовком, одной операцией записи
+func writeFrame(w io.Writer, record []byte) error {
+	frame := make([]byte, headerSize+len(record))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(record)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(record))
+	copy(frame[headerSize:], record)
+
+	_, err := w.Write(frame)
+
+	return err
+}
+
+// replayFile читает записи из файла
+func replayFile(path string, f func(record []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			//конец файла или не полностью записанный заголовок
+			return nil
+		}
+
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > maxRecordSize {
+			return nil
+		}
+
+		record := make([]byte, size)
+		if _, err := io.ReadFull(file, record); err != nil {
+			return nil
+		}
+
+		if crc32.ChecksumIEEE(record) != binary.LittleEndian.Uint32(header[4:8]) {
+			return nil
+		}
+
+		if err := f(record); err != nil {
+			return err
+		}
+	}
+}
+
+// listFiles номера сегментов и контрольных точек в директории, по возрастанию
+func listFiles(dir string) (segments, checkpoints []uint64, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, segmentPrefix) {
+			continue
+		}
+
+		switch {
+		case strings.HasSuffix(name, segmentSuffix):
+			if num, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64); err == nil {
+				segments = append(segments, num)
+			}
+
+		case strings.HasSuffix(name, checkpointSuffix):
+			if num, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), checkpointSuffix), 10, 64); err == nil {
+				checkpoints = append(checkpoints, num)
+			}
+		}
+	}
+
+	slices.Sort(segments)
+	slices.Sort(checkpoints)
+
+	return segments, checkpoints, nil
+}
+
+// fileName имя файла сегмента или контрольной точки
+func fileName(num uint64, suffix string) string {
+	return fmt.Sprintf("%s%016d%s", segmentPrefix, num, suffix)
+}
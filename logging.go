@@ -0,0 +1,196 @@
+package cachingstoragewithqueue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// типы событий, записываемые в поле event структурированных записей
+const (
+	logEventAdmissionRejected    = "admission_rejected"
+	logEventExecuted             = "executed"
+	logEventExecutionFailed      = "execution_failed"
+	logEventHandlerNotRegistered = "handler_not_registered"
+	logEventEvictionFailed       = "eviction_failed"
+	logEventSnapshotError        = "snapshot_error"
+	logEventWALError             = "wal_error"
+	logEventCacheFileError       = "cache_file_error"
+)
+
+// сообщение структурированных записей
+const logMessage = "cachingstoragewithqueue package"
+
+// log записывает структурированную запись с типом события event
+func (c *CacheStorageWithQueue[T]) log(level slog.Level, event string, attrs ...slog.Attr) {
+	c.handleLog(level, event, attrs)
+}
+
+// logError записывает структурированную запись об ошибке с типом события event
+func (c *CacheStorageWithQueue[T]) logError(event string, err error, attrs ...slog.Attr) {
+	c.handleLog(slog.LevelError, event, append(attrs, slog.Any("error", err)))
+}
+
+// handleLog формирует и передает запись обработчику, местом записи считается
+// функция вызвавшая log или logError
+func (c *CacheStorageWithQueue[T]) handleLog(level slog.Level, event string, attrs []slog.Attr) {
+	ctx := context.Background()
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	r := slog.NewRecord(time.Now(), level, logMessage, pcs[0])
+	r.AddAttrs(slog.String("event", event))
+	r.AddAttrs(attrs...)
+
+	_ = c.logger.Handler().Handle(ctx, r)
+}
+
+// writerHandler адаптер WriterLoggingData к интерфейсу slog.Handler
+type writerHandler struct {
+	writer WriterLoggingData
+	level  slog.Leveler
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewSlogHandler возвращает slog.Handler, который передает записи в WriterLoggingData.
+// Уровень записи передается как тип сообщения ("error", "warning", "info" или "debug"),
+// а сообщение содержит текст записи, её поля в виде key=value и ссылку на файл и номер
+// строки в файле. Записи с уровнем ниже level не передаются, если level не задан
+// используется slog.LevelInfo
+func NewSlogHandler(w WriterLoggingData, level slog.Leveler) slog.Handler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	return &writerHandler{writer: w, level: level}
+}
+
+func (h *writerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *writerHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+
+	writeAttr := func(prefix string, a slog.Attr) {
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+
+		b.WriteString(" ")
+		if prefix != "" {
+			b.WriteString(prefix + ".")
+		}
+		fmt.Fprintf(&b, "%s=%q", a.Key, a.Value.String())
+	}
+
+	//ключи полей добавленных через WithAttrs уже содержат имена групп
+	for _, a := range h.attrs {
+		writeAttr("", a)
+	}
+
+	prefix := strings.Join(h.groups, ".")
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(prefix, a)
+
+		return true
+	})
+
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fmt.Fprintf(&b, " %s:%d", frame.File, frame.Line)
+	}
+
+	h.writer.Write(msgTypeFromLevel(r.Level), b.String())
+
+	return nil
+}
+
+func (h *writerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	prefix := strings.Join(h.groups, ".")
+	nh.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if prefix != "" {
+			a.Key = prefix + "." + a.Key
+		}
+
+		nh.attrs = append(nh.attrs, a)
+	}
+
+	return &nh
+}
+
+func (h *writerHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	nh := *h
+	nh.groups = append(append([]string(nil), h.groups...), name)
+
+	return &nh
+}
+
+// slogWriter адаптер slog.Handler к интерфейсу WriterLoggingData
+type slogWriter struct {
+	handler slog.Handler
+}
+
+// NewWriterLoggingData возвращает WriterLoggingData, который передает сообщения в
+// slog.Handler. Тип сообщения определяет уровень записи: "error" - slog.LevelError,
+// "warning" или "warn" - slog.LevelWarn, "debug" - slog.LevelDebug, остальные типы
+// slog.LevelInfo, сам тип сообщения передается в поле msg_type
+func NewWriterLoggingData(h slog.Handler) WriterLoggingData {
+	return &slogWriter{handler: h}
+}
+
+func (sw *slogWriter) Write(msgType, msg string) bool {
+	ctx := context.Background()
+	level := levelFromMsgType(msgType)
+	if !sw.handler.Enabled(ctx, level) {
+		return true
+	}
+
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r.AddAttrs(slog.String("msg_type", msgType))
+
+	return sw.handler.Handle(ctx, r) == nil
+}
+
+// msgTypeFromLevel тип сообщения WriterLoggingData по уровню записи
+func msgTypeFromLevel(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warning"
+	case level >= slog.LevelInfo:
+		return "info"
+	}
+
+	return "debug"
+}
+
+// levelFromMsgType уровень записи по типу сообщения WriterLoggingData
+func levelFromMsgType(msgType string) slog.Level {
+	switch strings.ToLower(msgType) {
+	case "error":
+		return slog.LevelError
+	case "warning", "warn":
+		return slog.LevelWarn
+	case "debug":
+		return slog.LevelDebug
+	}
+
+	return slog.LevelInfo
+}
@@ -8,10 +8,10 @@ import (
 
 // GetSizeObjectToQueue размер очереди
 func (c *CacheStorageWithQueue[T]) GetSizeObjectToQueue() int {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	c.queue.mutex.RLock()
+	defer c.queue.mutex.RUnlock()
 
-	return len(c.queue.storages)
+	return c.queue.len()
 }
 
 // CleanQueue очистка очереди
@@ -19,40 +19,50 @@ func (c *CacheStorageWithQueue[T]) CleanQueue() {
 	c.queue.mutex.Lock()
 	defer c.queue.mutex.Unlock()
 
-	c.queue.storages = []CacheStorageHandler[T](nil)
+	c.queue.clean()
+	c.walClean(walOpCleanQueue)
 }
 
 // CleanCache очистка кэша
 func (c *CacheStorageWithQueue[T]) CleanCache() {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	c.cache.lockAll()
+	defer c.cache.unlockAll()
 
-	c.cache.storages = map[string]storageParameters[T]{}
+	for _, sh := range c.cache.shards {
+		sh.clean()
+	}
+	c.walClean(walOpCleanCache)
 }
 
-// PushObjectToQueue добавляет в очередь объектов новый объект
+// PushObjectToQueue добавляет в очередь объектов новый объект, если вспомогательный тип
+// реализует интерфейс DelayedHandler, объект не выполняется раньше времени NotBefore
 func (c *CacheStorageWithQueue[T]) PushObjectToQueue(v CacheStorageHandler[T]) {
-	c.queue.mutex.Lock()
-	defer c.queue.mutex.Unlock()
+	var notBefore time.Time
+	if dh, ok := v.(DelayedHandler); ok {
+		notBefore = dh.NotBefore()
+	}
 
-	c.queue.storages = append(c.queue.storages, v)
+	c.pushObjectToQueue(v, notBefore)
 }
 
-// PullObjectFromQueue забирает из очереди один новый объект или возвращает TRUE если очередь пуста
+// PullObjectFromQueue забирает из очереди один новый объект или возвращает TRUE если очередь пуста,
+// отложенные объекты, время выполнения которых еще не наступило, не забираются
 func (c *CacheStorageWithQueue[T]) PullObjectFromQueue() (CacheStorageHandler[T], bool) {
 	c.queue.mutex.Lock()
 	defer c.queue.mutex.Unlock()
 
+	c.queue.promote(time.Now())
+
 	var obj CacheStorageHandler[T]
 	size := len(c.queue.storages)
 	if size == 0 {
 		return obj, true
 	}
 
-	obj = c.queue.storages[0]
+	obj = c.pullQueueItem(c.queue.storages[0])
 
 	if size == 1 {
-		c.queue.storages = make([]CacheStorageHandler[T], 0)
+		c.queue.storages = make([]queueItem[T], 0)
 
 		return obj, false
 	}
@@ -69,22 +79,27 @@ func (c *CacheStorageWithQueue[T]) PullMaxObjectFromQueue() ([]CacheStorageHandl
 	c.queue.mutex.Lock()
 	defer c.queue.mutex.Unlock()
 
-	list := make([]CacheStorageHandler[T], 0, c.isAsync)
+	c.queue.promote(time.Now())
+
+	isAsync := c.getConcurrency()
+	list := make([]CacheStorageHandler[T], 0, isAsync)
 	size := len(c.queue.storages)
 	if size == 0 {
 		return list, true
 	}
 
-	if c.isAsync < len(c.queue.storages) {
-		list = append(list, c.queue.storages[:c.isAsync]...)
-		c.queue.storages = c.queue.storages[c.isAsync:]
+	if isAsync < len(c.queue.storages) {
+		for _, item := range c.queue.storages[:isAsync] {
+			list = append(list, c.pullQueueItem(item))
+		}
+		c.queue.storages = c.queue.storages[isAsync:]
 
 		return list, false
 	}
 
 	i := 0
 	for ; i < len(c.queue.storages); i++ {
-		list = append(list, c.queue.storages[i])
+		list = append(list, c.pullQueueItem(c.queue.storages[i]))
 	}
 	c.queue.storages = c.queue.storages[i:]
 
@@ -93,105 +108,143 @@ func (c *CacheStorageWithQueue[T]) PullMaxObjectFromQueue() ([]CacheStorageHandl
 
 // AddObjectToCache добавляет новый объект в кэш
 func (c *CacheStorageWithQueue[T]) AddObjectToCache(key string, value CacheStorageHandler[T]) error {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	storage, event, err := c.addObjectToCache(sh, key, value)
+	if err != nil {
+		c.walOp(walOpReject, key)
+	} else {
+		c.walStore(walOpAdmit, key, storage)
+	}
+	sh.mutex.Unlock()
+
+	c.emit(event)
 
+	return err
+}
+
+// addObjectToCache добавляет новый объект в сегмент кэша и возвращает сохраненные параметры
+// объекта и событие жизненного цикла, блокировка сегмента должна быть выполнена вызывающей стороной
+func (c *CacheStorageWithQueue[T]) addObjectToCache(sh *cacheShard[T], key string, value CacheStorageHandler[T]) (storageParameters[T], Event[T], error) {
 	//если поиск подобного объекта по ключу не дал результатов то просто добавляем объект
-	storage, ok := c.cache.storages[key]
+	storage, ok := sh.storages.get(key)
 	if !ok {
-		c.cache.storages[key] = storageParameters[T]{
+		//объект с таким ключом был успешно выполнен ранее и уже удалён из кэша
+		if c.dedup != nil && c.dedup.contains(key) {
+			c.stats.duplicatesRejected.Add(1)
+
+			return storage, newEvent(EventDuplicate, key, value.GetObject(), ReasonAlreadyProcessed), fmt.Errorf("the object with key ID '%s' has already been successfully processed earlier, adding an object to the cache is not performed", key)
+		}
+
+		storage = storageParameters[T]{
 			timeMain:       time.Now(),
-			timeExpiry:     time.Now().Add(c.maxTtl),
+			timeExpiry:     time.Now().Add(c.getMaxTtl()),
 			originalObject: value.GetObject(),
-			cacheFunc:      value.GetFunc(),
+			cacheFunc:      c.objectFunc(key, value),
+			handlerName:    getHandlerName(value),
+			hash:           getHash(value),
 		}
+		sh.set(key, storage)
+		c.stats.admitted.Add(1)
 
-		return nil
+		return storage, newEvent(EventAdmitted, key, storage.originalObject, ReasonNew), nil
 	}
 
 	//найден объект у которого ключ совпадает с объектом принятом в обработку
 
 	//объект в настоящее время выполняется
 	if storage.isExecution {
-		return fmt.Errorf("an object has been received whose key ID '%s' matches the already running object, ignore it", key)
+		return storage, Event[T]{}, fmt.Errorf("an object has been received whose key ID '%s' matches the already running object, ignore it", key)
 	}
 
-	//сравнение объектов из кэша и полученного из очереди
-	if value.Comparison(storage.originalObject) {
-		return fmt.Errorf("objects with key ID '%s' are completely identical, adding an object to the cache is not performed", key)
+	//сравнение объектов из кэша и полученного из очереди, если отпечатки объектов
+	//известны, сравниваются отпечатки, иначе вызывается Comparison
+	hash := getHash(value)
+	if isIdentical, ok := c.compareHashes(storage.hash, hash); ok {
+		if isIdentical {
+			c.stats.duplicatesRejected.Add(1)
+
+			return storage, newEvent(EventDuplicate, key, value.GetObject(), ReasonIdenticalHash), fmt.Errorf("objects with key ID '%s' have identical hashes, adding an object to the cache is not performed", key)
+		}
+	} else if value.Comparison(storage.originalObject) {
+		c.stats.duplicatesRejected.Add(1)
+
+		return storage, newEvent(EventDuplicate, key, value.GetObject(), ReasonIdentical), fmt.Errorf("objects with key ID '%s' are completely identical, adding an object to the cache is not performed", key)
 	}
 
+	//сохраняем заменяемую версию объекта, если включено хранение версий
+	storage.history = c.history.push(storage.history, storage.originalObject, storage.timeMain)
+
 	//если объекты разные то выполяем модификацию объекта который находится в кеше
 	newObject := value.MatchingAndReplacement(storage.originalObject)
 
 	//если объекты с одним и тем же ключём разные, заменяем объект в кэше более новым
 	storage.timeMain = time.Now()
-	storage.timeExpiry = time.Now().Add(c.maxTtl)
+	storage.timeExpiry = time.Now().Add(c.getMaxTtl())
 	storage.isExecution = false
 	storage.isCompletedSuccessfully = false
+	storage.lastError = nil
+	storage.result = nil
 	storage.originalObject = newObject
-	storage.cacheFunc = value.GetFunc()
+	storage.cacheFunc = c.objectFunc(key, value)
+	storage.handlerName = getHandlerName(value)
+	storage.hash = hash
 
 	//добавление нового объекта в кэш
-	c.cache.storages[key] = storage
+	sh.set(key, storage)
+	c.stats.replaced.Add(1)
 
-	return nil
+	return storage, newEvent(EventReplaced, key, newObject, ReasonReplaced), nil
 }
 
 // GetOldestObjectFromCache возвращает индекс самого старого объекта
 func (c *CacheStorageWithQueue[T]) GetOldestObjectFromCache() string {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
 
 	return c.getOldestObjectFromCache()
 }
 
 // GetObjectFromCacheByKey возвращает объект из кэша по ключу
 func (c *CacheStorageWithQueue[T]) GetObjectFromCacheByKey(key string) (T, bool) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	sh := c.cache.shard(key)
+	sh.mutex.RLock()
+	defer sh.mutex.RUnlock()
 
-	storage, ok := c.cache.storages[key]
+	storage, ok := sh.storages.get(key)
 
 	return storage.originalObject, ok
 }
 
 // GetFuncFromCacheByKey возвращает исполняемую функцию из кэша по ключу
 func (c *CacheStorageWithQueue[T]) GetFuncFromCacheByKey(key string) (func(int) bool, bool) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	sh := c.cache.shard(key)
+	sh.mutex.RLock()
+	defer sh.mutex.RUnlock()
+
+	storage, ok := sh.storages.get(key)
+	if !ok {
+		return nil, false
+	}
 
-	return c.getFuncFromCacheByKey(key)
+	return c.getFunc(key, storage), true
 }
 
 // GetObjectFromCacheMinTimeExpiry возвращает из кэша объект, функция которого в настоящее время
 // не выполняется, не была успешно выполнена и время истечения жизни объекта которой самое меньшее,
 // то есть фактически, ищется наиболее старые объекты
 func (c *CacheStorageWithQueue[T]) GetObjectFromCacheMinTimeExpiry() (key string, obj T) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
 
-	var early time.Time
-	for k, v := range c.cache.storages {
-		if v.isExecution || v.isCompletedSuccessfully {
-			continue
-		}
-
-		if key == "" {
-			key = k
-			early = v.timeExpiry
-			obj = v.originalObject
-
-			continue
-		}
-
-		if v.timeExpiry.Before(early) {
-			key = k
-			early = v.timeExpiry
-			obj = v.originalObject
-		}
+	key, sh := c.cache.oldestReady()
+	if sh == nil {
+		return
 	}
 
+	storage, _ := sh.storages.get(key)
+	obj = storage.originalObject
+
 	return
 }
 
@@ -199,94 +252,58 @@ func (c *CacheStorageWithQueue[T]) GetObjectFromCacheMinTimeExpiry() (key string
 // не выполняется, не была успешно выполнена и время истечения жизни объекта которой самое меньшее,
 // то есть фактически, ищется наиболее старые объекты
 func (c *CacheStorageWithQueue[T]) GetFuncFromCacheMinTimeExpiry() (key string, f func(int) bool) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
-	//тут не выполняется проверка для найденного самого старого объекта
-	// выполняется ли функция в настоящее время
-	//и была ли функция успешно выполнена
-
-	var early time.Time
-	for k, v := range c.cache.storages {
-		if v.isExecution || v.isCompletedSuccessfully {
-			continue
-		}
-
-		if key == "" {
-			key = k
-			early = v.timeExpiry
-			f = v.cacheFunc
-
-			continue
-		}
-
-		if v.timeExpiry.Before(early) {
-			key = k
-			early = v.timeExpiry
-			f = v.cacheFunc
-		}
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
+
+	//индексы ready сегментов содержат только те объекты, функция которых в настоящее
+	//время не выполняется и не была успешно выполнена
+	key, sh := c.cache.oldestReady()
+	if sh == nil {
+		return
 	}
 
-	return key, f
+	storage, _ := sh.storages.get(key)
+
+	return key, c.getFunc(key, storage)
 }
 
 // GetCacheSize возвращает общее количество объектов в кэше
 func (c *CacheStorageWithQueue[T]) GetCacheSize() int {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
+	var size int
+	for _, sh := range c.cache.shards {
+		sh.mutex.RLock()
+		size += sh.storages.len()
+		sh.mutex.RUnlock()
+	}
 
-	return len(c.cache.storages)
+	return size
 }
 
 // GetIndexesWithIsExecutionStatus возвращает список индексов объектов, по которым выполяется обработка
 func (c *CacheStorageWithQueue[T]) GetIndexesWithIsExecutionStatus() []string {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
-	var indexes []string
-
-	for index, storage := range c.cache.storages {
-		if !storage.isExecution {
-			continue
-		}
-
-		indexes = append(indexes, index)
-	}
-
-	return indexes
+	return c.getIndexes(func(storage storageParameters[T]) bool {
+		return storage.isExecution
+	})
 }
 
 // GetIndexesWithIsCompletedSuccessfully возвращает список индексов объектов, которые были успешно выполнены
 func (c *CacheStorageWithQueue[T]) GetIndexesWithIsCompletedSuccessfully() []string {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
-	var indexes []string
-
-	for index, storage := range c.cache.storages {
-		if !storage.isCompletedSuccessfully {
-			continue
-		}
-
-		indexes = append(indexes, index)
-	}
-
-	return indexes
+	return c.getIndexes(func(storage storageParameters[T]) bool {
+		return storage.isCompletedSuccessfully
+	})
 }
 
 // SetTimeExpiry устанавливает или обновляет значение параметра timeExpiry
 func (c *CacheStorageWithQueue[T]) SetTimeExpiry(key string) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
 
-	c.setTimeExpiry(key)
+	sh.setTimeExpiry(key, c.getMaxTtl())
 }
 
 // GetIsExecution возвращает статус параметра isExecution объекта в кэше и найден ли такой объект по ключу
 func (c *CacheStorageWithQueue[T]) GetIsExecution(key string) (status bool, isExist bool) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
 	sp, ok := c.getStorageParameters(key)
 	status = sp.isExecution
 	isExist = ok
@@ -296,25 +313,24 @@ func (c *CacheStorageWithQueue[T]) GetIsExecution(key string) (status bool, isEx
 
 // SetIsExecutionTrue устанавливает значение параметра isExecution
 func (c *CacheStorageWithQueue[T]) SetIsExecutionTrue(key string) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
 
-	c.setIsExecutionTrue(key)
+	sh.setIsExecution(key, true)
 }
 
 // SetIsExecutionFalse устанавливает значение параметра isExecution
 func (c *CacheStorageWithQueue[T]) SetIsExecutionFalse(key string) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
 
-	c.setIsExecutionFalse(key)
+	sh.setIsExecution(key, false)
 }
 
 // GetIsCompletedSuccessfully возвращает статус параметра isCompletedSuccessfully объекта в кэше и найден ли такой объект по ключу
 func (c *CacheStorageWithQueue[T]) GetIsCompletedSuccessfully(key string) (status bool, isExist bool) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
 	sp, ok := c.getStorageParameters(key)
 	status = sp.isCompletedSuccessfully
 	isExist = ok
@@ -324,194 +340,272 @@ func (c *CacheStorageWithQueue[T]) GetIsCompletedSuccessfully(key string) (statu
 
 // SetIsCompletedSuccessfullyTrue устанавливает значение параметра isCompletedSuccessfully
 func (c *CacheStorageWithQueue[T]) SetIsCompletedSuccessfullyTrue(key string) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
 
-	c.setIsCompletedSuccessfullyTrue(key)
+	sh.setIsCompletedSuccessfully(key, true)
 }
 
 // SetIsCompletedSuccessfullyFalse устанавливает значение параметра isCompletedSuccessfully
 func (c *CacheStorageWithQueue[T]) SetIsCompletedSuccessfullyFalse(key string) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
 
-	c.setIsCompletedSuccessfullyFalse(key)
+	sh.setIsCompletedSuccessfully(key, false)
 }
 
 // GetNumberExecutionAttempts возвращает количество попыток выполнения функции
 func (c *CacheStorageWithQueue[T]) GetNumberExecutionAttempts(key string) (num int, status bool) {
-	c.cache.mutex.RLock()
-	defer c.cache.mutex.RUnlock()
-
-	if storage, ok := c.cache.storages[key]; ok {
-		num = storage.numberExecutionAttempts
-		status = ok
-	}
+	sp, ok := c.getStorageParameters(key)
 
-	return num, status
+	return sp.numberExecutionAttempts, ok
 }
 
 // ChangeValues меняет значение информирующее об успешности выполнения функции и
 // статус выполнения функции на 'функция не обрабатывается'
 func (c *CacheStorageWithQueue[T]) ChangeValues(index string, isSuccess bool) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	c.completeExecution(index, 0, isSuccess, nil)
+}
+
+// completeExecution завершает выполнение функции объекта, generation номер запуска функции,
+// 0 - текущий запуск, err причина неуспешного выполнения, если она известна. Если объекта
+// нет в кэше или номер его последнего запуска не совпадает с generation, например объект
+// был отменен и заменен новым объектом с тем же ключом, результат выполнения отбрасывается
+func (c *CacheStorageWithQueue[T]) completeExecution(index string, generation uint64, isSuccess bool, err error) {
+	var event Event[T]
+
+	sh := c.cache.shard(index)
+	sh.mutex.Lock()
+	storage, ok := sh.storages.get(index)
+	if !ok || (generation != 0 && storage.generation != generation) {
+		sh.mutex.Unlock()
+		c.finishExecution(index, generation)
+
+		return
+	}
+
+	storage.isCompletedSuccessfully = isSuccess
+	//функция не обрабатывается
+	storage.isExecution = false
 
 	if isSuccess {
-		c.setIsCompletedSuccessfullyTrue(index)
+		c.stats.succeeded.Add(1)
+		c.metrics.attemptsSucceeded.Observe(float64(storage.numberExecutionAttempts))
+		event = newEvent(EventSucceeded, index, storage.originalObject, ReasonSucceeded)
 	} else {
-		c.setIsCompletedSuccessfullyFalse(index)
+		c.stats.failed.Add(1)
+		c.metrics.attemptsFailed.Observe(float64(storage.numberExecutionAttempts))
+		if err != nil {
+			storage.lastError = fmt.Errorf("execution attempt %d of the function for the object with key ID '%s' was unsuccessful: %w", storage.numberExecutionAttempts, index, err)
+		} else {
+			storage.lastError = fmt.Errorf("execution attempt %d of the function for the object with key ID '%s' was unsuccessful", storage.numberExecutionAttempts, index)
+		}
+		event = newEvent(EventFailed, index, storage.originalObject, ReasonFailed)
 	}
+	finishRecurringRun(&storage)
 
-	//функция не обрабатывается
-	c.setIsExecutionFalse(index)
+	event.Result = storage.result
+
+	sh.set(index, storage)
+	c.walComplete(index, storage)
+	sh.mutex.Unlock()
+
+	c.finishExecution(index, generation)
+	c.emit(event)
 }
 
 // ChangeExecution меняет статус выполнения функции на 'функция в обработке', увеличивает кол-во
 // попыток обработки функции на 1
 func (c *CacheStorageWithQueue[T]) ChangeExecution(index string) {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
-
-	//функция для данного объекта выполняется
-	c.setIsExecutionTrue(index)
-	// увеличиваем количество попыток выполнения функции
-	c.increaseNumberExecutionAttempts(index)
+	c.startExecution(index)
 }
 
 // DeleteForTimeExpiryObjectFromCache удаляет все объекты у которых истекло время жизни, без учета других параметров
 func (c *CacheStorageWithQueue[T]) DeleteForTimeExpiryObjectFromCache() {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	now := time.Now()
+	for _, sh := range c.cache.shards {
+		var events []Event[T]
+
+		sh.mutex.Lock()
+		for {
+			key, timeExpiry, ok := sh.expiry.peek()
+			if !ok || !timeExpiry.Before(now) {
+				break
+			}
 
-	for key, storage := range c.cache.storages {
-		if storage.timeExpiry.Before(time.Now()) {
-			delete(c.cache.storages, key)
+			events = append(events, c.evictObject(sh, key, ReasonTtl))
 		}
+		sh.mutex.Unlock()
+
+		c.emit(events...)
 	}
 }
 
 // DeleteOldestObjectFromCache поиск и удаление самого старого объекта в кэше
 func (c *CacheStorageWithQueue[T]) DeleteOldestObjectFromCache() error {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	events, err := c.deleteOldestObjects()
+	c.emit(events...)
+
+	return err
+}
+
+// deleteOldestObjects удаляет самые старые объекты в кэше и возвращает события
+// их вытеснения
+func (c *CacheStorageWithQueue[T]) deleteOldestObjects() ([]Event[T], error) {
+	var events []Event[T]
+
+	//самый старый объект ищется среди всех сегментов, поэтому блокируются все сегменты
+	c.cache.lockAll()
+	defer c.cache.unlockAll()
 
 	countObjDel := 1
 
 	//проверяем, включен ли асинхронный режим и если да, то выполняем удаление
 	//группы самых старых объектов в кэше
-	if c.isAsync < c.cache.maxSize && c.isAsync >= 2 {
-		countObjDel = c.isAsync
+	if isAsync := c.getConcurrency(); isAsync < c.cache.getMaxSize() && isAsync >= 2 {
+		countObjDel = isAsync
 	}
 
 	//получаем самый старый объект в кэше
 	for range countObjDel {
-		index := c.getOldestObjectFromCache()
-		if storage, ok := c.cache.storages[index]; ok {
-			if !storage.isExecution && storage.isCompletedSuccessfully {
-				delete(c.cache.storages, index)
-			} else if storage.numberExecutionAttempts == 3 {
-				delete(c.cache.storages, index)
-			} else {
-				return fmt.Errorf("the object with id '%s' cannot be deleted, it may be in progress", index)
-			}
+		index, sh := c.cache.oldest()
+		if sh == nil {
+			continue
+		}
+
+		//выполняющийся объект не удаляется никогда, а не выполненный успешно объект
+		//удаляется только после всех попыток выполнения
+		storage, _ := sh.storages.get(index)
+		if !storage.isExecution && (storage.isCompletedSuccessfully || storage.numberExecutionAttempts >= maxExecutionAttempts) {
+			events = append(events, c.evictObject(sh, index, ReasonSize))
+		} else {
+			return events, fmt.Errorf("the object with id '%s' cannot be deleted, it may be in progress", index)
 		}
 	}
 
-	return nil
+	return events, nil
 }
 
-// getOldestObjectFromCache возвращает индекс самого старого объекта
-func (c *CacheStorageWithQueue[T]) getOldestObjectFromCache() string {
-	var (
-		index      string
-		timeExpiry time.Time
-	)
+// startExecution меняет статус выполнения функции на 'функция в обработке', увеличивает
+// кол-во попыток обработки функции на 1 и возвращает исполняемую функцию и номер запуска
+func (c *CacheStorageWithQueue[T]) startExecution(index string) (func(int) bool, uint64, bool) {
+	storage, ok := c.markExecution(index)
+	if !ok {
+		return nil, 0, false
+	}
 
-	for k, v := range c.cache.storages {
-		if index == "" {
-			index = k
-			timeExpiry = v.timeExpiry
+	return c.getFunc(index, storage), storage.generation, true
+}
 
-			continue
-		}
+// markExecution меняет статус выполнения функции на 'функция в обработке', увеличивает
+// кол-во попыток обработки функции на 1, назначает новый номер запуска и возвращает
+// параметры объекта
+func (c *CacheStorageWithQueue[T]) markExecution(index string) (storageParameters[T], bool) {
+	generation := c.cache.generation.Add(1)
 
-		if v.timeExpiry.Before(timeExpiry) {
-			index = k
-			timeExpiry = v.timeExpiry
-		}
+	sh := c.cache.shard(index)
+	sh.mutex.Lock()
+	storage, ok := sh.startExecution(index, generation)
+	sh.mutex.Unlock()
+	if !ok {
+		return storage, false
 	}
+	c.stats.executed.Add(1)
+	c.beginExecution(index, generation)
+	c.emit(newEvent(EventStarted, index, storage.originalObject, ReasonStarted))
 
-	return index
+	return storage, true
 }
 
-// getStorageParameters получить общие параметры объекта из кэша
-func (c *CacheStorageWithQueue[T]) getStorageParameters(key string) (storageParameters[T], bool) {
-	if storage, ok := c.cache.storages[key]; ok {
-		return storage, true
+// evictObject удаляет объект из сегмента кэша при его вытеснении, по истечении времени
+// жизни или при превышении размера кэша, ключ успешно выполненного объекта запоминается
+// в окне дедупликации, а объект функция которого выполнялась, но не была выполнена успешно,
+// перемещается в очередь недоставленных объектов, возвращает событие вытеснения, блокировка сегмента должна быть
+// выполнена вызывающей стороной
+func (c *CacheStorageWithQueue[T]) evictObject(sh *cacheShard[T], key string, reason Reason) Event[T] {
+	storage, ok := sh.storages.get(key)
+	if !ok {
+		return Event[T]{}
 	}
 
-	return storageParameters[T]{}, false
-}
-
-// getFuncFromCacheByKey возвращает исполняемую функцию из кэша по ключу
-func (c *CacheStorageWithQueue[T]) getFuncFromCacheByKey(key string) (func(int) bool, bool) {
-	storage, ok := c.cache.storages[key]
+	if c.dedup != nil && storage.isCompletedSuccessfully && storage.numberExecutionAttempts > 0 {
+		c.dedup.remember(key)
+	}
 
-	return storage.cacheFunc, ok
-}
+	if c.dead != nil && isUndelivered(storage) {
+		c.dead.add(key, storage, reason)
+	}
 
-// deleteOldestObjectFromCache удаляет самый старый объект по timeMain
-// без учета других параметров, таких как isCompletedSuccessfully и isExecution
-func (c *CacheStorageWithQueue[T]) deleteOldestObjectFromCache() {
-	delete(c.cache.storages, c.getOldestObjectFromCache())
-}
+	sh.del(key)
+	c.countEviction(reason)
+	c.walDelete(key)
 
-// setTimeExpiry устанавливает или обновляет значение параметра timeExpiry
-func (c *CacheStorageWithQueue[T]) setTimeExpiry(key string) {
-	if storage, ok := c.cache.storages[key]; ok {
-		storage.timeExpiry = time.Now().Add(c.maxTtl)
-		c.cache.storages[key] = storage
+	eventType := EventEvicted
+	if reason == ReasonTtl {
+		eventType = EventExpired
 	}
+
+	return newEvent(eventType, key, storage.originalObject, reason)
 }
 
-// setIsExecutionTrue устанавливает значение параметра isExecution
-func (c *CacheStorageWithQueue[T]) setIsExecutionTrue(key string) {
-	if storage, ok := c.cache.storages[key]; ok {
-		storage.isExecution = true
-		c.cache.storages[key] = storage
+// pullQueueItem учитывает время ожидания объекта в очереди и возвращает объект, для
+// отложенных объектов время ожидания отсчитывается от наступления времени выполнения
+func (c *CacheStorageWithQueue[T]) pullQueueItem(item queueItem[T]) CacheStorageHandler[T] {
+	timeStart := item.timePush
+	if item.notBefore.After(timeStart) {
+		timeStart = item.notBefore
 	}
+	c.metrics.queueWait.Observe(time.Since(timeStart).Seconds())
+
+	return item.handler
 }
 
-// setIsExecutionFalse устанавливает значение параметра isExecution
-func (c *CacheStorageWithQueue[T]) setIsExecutionFalse(key string) {
-	if storage, ok := c.cache.storages[key]; ok {
-		storage.isExecution = false
-		c.cache.storages[key] = storage
-	}
+// getOldestObjectFromCache возвращает индекс самого старого объекта
+func (c *CacheStorageWithQueue[T]) getOldestObjectFromCache() string {
+	index, _ := c.cache.oldest()
+
+	return index
 }
 
-// setIsCompletedSuccessfullyTrue устанавливает значение параметра isCompletedSuccessfully
-func (c *CacheStorageWithQueue[T]) setIsCompletedSuccessfullyTrue(key string) {
-	if storage, ok := c.cache.storages[key]; ok {
-		storage.isCompletedSuccessfully = true
-		c.cache.storages[key] = storage
-	}
+// getStorageParameters получить общие параметры объекта из кэша
+func (c *CacheStorageWithQueue[T]) getStorageParameters(key string) (storageParameters[T], bool) {
+	sh := c.cache.shard(key)
+	sh.mutex.RLock()
+	defer sh.mutex.RUnlock()
+
+	storage, ok := sh.storages.get(key)
+
+	return storage, ok
 }
 
-// setIsCompletedSuccessfullyFalse устанавливает значение параметра isCompletedSuccessfully
-func (c *CacheStorageWithQueue[T]) setIsCompletedSuccessfullyFalse(key string) {
-	if storage, ok := c.cache.storages[key]; ok {
-		storage.isCompletedSuccessfully = false
-		c.cache.storages[key] = storage
+// getIndexes возвращает список индексов объектов, удовлетворяющих условию, условие проверяется
+// по параметрам объектов без самих объектов, поэтому файловое хранилище не читает файл
+func (c *CacheStorageWithQueue[T]) getIndexes(f func(storageParameters[T]) bool) []string {
+	var indexes []string
+
+	for _, sh := range c.cache.shards {
+		sh.mutex.RLock()
+		for index, storage := range sh.storages.states() {
+			if !f(storage) {
+				continue
+			}
+
+			indexes = append(indexes, index)
+		}
+		sh.mutex.RUnlock()
 	}
+
+	return indexes
 }
 
-// increaseNumberExecutionAttempts увеличивает количество попыток выполнения функции
-func (c *CacheStorageWithQueue[T]) increaseNumberExecutionAttempts(key string) {
-	if storage, ok := c.cache.storages[key]; ok {
-		storage.numberExecutionAttempts = storage.numberExecutionAttempts + 1
-		c.cache.storages[key] = storage
+// deleteOldestObjectFromCache удаляет самый старый объект по timeMain
+// без учета других параметров, таких как isCompletedSuccessfully и isExecution
+func (c *CacheStorageWithQueue[T]) deleteOldestObjectFromCache() {
+	if index, sh := c.cache.oldest(); sh != nil {
+		sh.del(index)
+		c.walDelete(index)
 	}
 }
 
@@ -524,11 +618,33 @@ func (wl *writeLog) Write(msgType, msg string) bool {
 //*********** Методы необходимые для выполнения дополнительного тестирования ************
 
 func (c *CacheStorageWithQueue[T]) GetCacheMaxSize_Test() int {
-	return c.cache.maxSize
+	return c.cache.getMaxSize()
 }
 
 func (c *CacheStorageWithQueue[T]) GetIsAsync_Test() int {
-	return c.isAsync
+	return c.getConcurrency()
+}
+
+// AdaptConcurrency_Test учитывает результат выполнения функции при адаптивном управлении
+// количеством потоков (только для теста)
+func (c *CacheStorageWithQueue[T]) AdaptConcurrency_Test(isSuccess bool, duration time.Duration) {
+	c.adaptConcurrency(isSuccess, duration)
+}
+
+// ShiftDedupWindow_Test сдвигает время создания текущего поколения вероятностного окна
+// дедупликации на d в прошлое (только для теста)
+func (c *CacheStorageWithQueue[T]) ShiftDedupWindow_Test(d time.Duration) {
+	if db, ok := c.dedup.(*dedupBloom); ok {
+		db.mutex.Lock()
+		db.rotated = db.rotated.Add(-d)
+		db.mutex.Unlock()
+	}
+}
+
+// StartRecurringRuns_Test начинает очередные запуски периодических объектов, время которых
+// наступило (только для теста)
+func (c *CacheStorageWithQueue[T]) StartRecurringRuns_Test() {
+	c.startRecurringRuns()
 }
 
 // SyncExecution_Test выполняет синхронную обработку функций из кэша (только для теста)
@@ -541,24 +657,33 @@ func (c *CacheStorageWithQueue[T]) AsyncExecution_Test(ctx context.Context, chSt
 	c.asyncExecution(ctx)
 }
 
+// ReadyBatch_Test возвращает ключи объектов, которые будут выполнены следующей группой
+// (только для теста)
+func (c *CacheStorageWithQueue[T]) ReadyBatch_Test(size int) []string {
+	keys, _ := c.readyBatch(size)
+
+	return keys
+}
+
 // AddObjectToCache_Test добавляет новый объект в хранилище (только для теста)
 func (c *CacheStorageWithQueue[T]) AddObjectToCache_Test(key string, timeExpiry time.Time, value CacheStorageHandler[T]) error {
-	c.cache.mutex.Lock()
-	defer c.cache.mutex.Unlock()
+	c.cache.lockAll()
+	defer c.cache.unlockAll()
 
-	if len(c.cache.storages) >= c.cache.maxSize {
+	if c.cache.size() >= c.cache.getMaxSize() {
 		//удаление самого старого объекта, осуществляется по параметру timeMain
 		c.deleteOldestObjectFromCache()
 	}
 
-	storage, ok := c.cache.storages[key]
+	sh := c.cache.shard(key)
+	storage, ok := sh.storages.get(key)
 	if !ok {
-		c.cache.storages[key] = storageParameters[T]{
+		sh.set(key, storageParameters[T]{
 			timeMain:       time.Now(),
 			timeExpiry:     timeExpiry,
 			originalObject: value.GetObject(),
 			cacheFunc:      value.GetFunc(),
-		}
+		})
 
 		return nil
 	}
@@ -579,10 +704,11 @@ func (c *CacheStorageWithQueue[T]) AddObjectToCache_Test(key string, timeExpiry
 	storage.timeExpiry = timeExpiry
 	storage.isExecution = false
 	storage.isCompletedSuccessfully = false
+	storage.lastError = nil
 	storage.originalObject = value.MatchingAndReplacement(storage.originalObject)
 	storage.cacheFunc = value.GetFunc()
 
-	c.cache.storages[key] = storage
+	sh.set(key, storage)
 
 	return nil
 }
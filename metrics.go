@@ -0,0 +1,116 @@
+package cachingstoragewithqueue
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/metrics"
+)
+
+// префикс имен метрик
+const metricsPrefix = "cachingstoragewithqueue_"
+
+// metricsCollector гистограммы распределения времени выполнения и ожидания объектов
+type metricsCollector struct {
+	//время выполнения функций объектов, в секундах
+	handlerDuration *metrics.Histogram
+	//время ожидания объектов в очереди, в секундах
+	queueWait *metrics.Histogram
+	//номер попытки, на которой функция объекта выполнилась успешно
+	attemptsSucceeded *metrics.Histogram
+	//номер попытки, на которой функция объекта выполнилась неуспешно
+	attemptsFailed *metrics.Histogram
+}
+
+// newMetricsCollector новые гистограммы
+func newMetricsCollector() metricsCollector {
+	attempts := []float64{1, 2, 3, 4, 5, 10}
+
+	return metricsCollector{
+		handlerDuration:   metrics.NewHistogram(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60),
+		queueWait:         metrics.NewHistogram(0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600),
+		attemptsSucceeded: metrics.NewHistogram(attempts...),
+		attemptsFailed:    metrics.NewHistogram(attempts...),
+	}
+}
+
+// MetricsHandler возвращает http.Handler, отдающий метрики хранилища в текстовом формате
+// Prometheus. Все метрики помечаются меткой instance с заданным именем экземпляра хранилища
+func (c *CacheStorageWithQueue[T]) MetricsHandler(instance string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := &bytes.Buffer{}
+		c.writeMetrics(buf, instance)
+
+		w.Header().Set("Content-Type", metrics.ContentType)
+		w.Write(buf.Bytes())
+	})
+}
+
+// writeMetrics записывает метрики хранилища в текстовом формате Prometheus
+func (c *CacheStorageWithQueue[T]) writeMetrics(buf *bytes.Buffer, instance string) {
+	label := metrics.Label{Name: "instance", Value: instance}
+	stats := c.Stats()
+
+	listCounters := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"pushed_total", "Number of objects pushed to the queue.", stats.Pushed},
+		{"admitted_total", "Number of new objects admitted into the cache.", stats.Admitted},
+		{"duplicates_rejected_total", "Number of objects rejected as duplicates.", stats.DuplicatesRejected},
+		{"replaced_total", "Number of cached objects replaced via MatchingAndReplacement.", stats.Replaced},
+		{"executed_total", "Number of handler executions started.", stats.Executed},
+		{"succeeded_total", "Number of successful handler executions.", stats.Succeeded},
+		{"failed_total", "Number of failed handler executions.", stats.Failed},
+		{"evicted_ttl_total", "Number of objects evicted from the cache by TTL.", stats.EvictedByTtl},
+		{"evicted_size_total", "Number of objects evicted from the cache by size.", stats.EvictedBySize},
+		{"events_dropped_total", "Number of lifecycle events dropped because a subscriber buffer was full.", stats.EventsDropped},
+		{"hash_duplicates_total", "Number of duplicates detected by matching object hashes.", stats.HashDuplicates},
+		{"hash_comparisons_skipped_total", "Number of objects replaced by differing hashes without calling Comparison.", stats.HashComparisonsSkipped},
+	}
+	for _, v := range listCounters {
+		metrics.WriteCounter(buf, metricsPrefix+v.name, v.help, v.value, label)
+	}
+
+	listGauges := []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"queue_length", "Current number of objects in the queue.", stats.QueueLength},
+		{"queue_delayed", "Current number of queued objects waiting for their not-before time.", stats.QueueDelayed},
+		{"cache_size", "Current number of objects in the cache.", stats.CacheSize},
+		{"cache_max_size", "Maximum number of objects in the cache.", c.cache.getMaxSize()},
+		{"running", "Current number of running handlers.", stats.Running},
+		{"concurrency_limit", "Current limit of concurrently running handlers.", stats.ConcurrencyLimit},
+	}
+	for _, v := range listGauges {
+		metrics.WriteGauge(buf, metricsPrefix+v.name, v.help, float64(v.value), label)
+	}
+
+	c.metrics.handlerDuration.Write(buf, metricsPrefix+"handler_duration_seconds", "Handler execution time in seconds.", label)
+	c.metrics.queueWait.Write(buf, metricsPrefix+"queue_wait_seconds", "Time objects spent waiting in the queue in seconds.", label)
+	c.metrics.attemptsSucceeded.Write(buf, metricsPrefix+"attempts_succeeded", "Attempt number on which a handler execution succeeded.", label)
+	c.metrics.attemptsFailed.Write(buf, metricsPrefix+"attempts_failed", "Attempt number on which a handler execution failed.", label)
+}
+
+// executeFunc выполняет функцию объекта с учетом и логированием времени её выполнения
+func (c *CacheStorageWithQueue[T]) executeFunc(index string, f func(int) bool) bool {
+	start := time.Now()
+	status := f(0)
+	duration := time.Since(start)
+	c.metrics.handlerDuration.Observe(duration.Seconds())
+	c.adaptConcurrency(status, duration)
+
+	attempt, _ := c.GetNumberExecutionAttempts(index)
+	if status {
+		c.log(slog.LevelDebug, logEventExecuted, slog.String("id", index), slog.Int("attempt", attempt), slog.Duration("duration", duration))
+	} else {
+		c.log(slog.LevelInfo, logEventExecutionFailed, slog.String("id", index), slog.Int("attempt", attempt), slog.Duration("duration", duration))
+	}
+
+	return status
+}
@@ -3,10 +3,11 @@ package cachingstoragewithqueue
 import (
 	"context"
 	"errors"
-	"fmt"
+	"log/slog"
+	"os"
 	"time"
 
-	"github.com/av-belyakov/cachingstoragewithqueue/internal/supportingfunctions"
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/wal"
 )
 
 // NewCacheStorage создает новое кэширующее хранилище, а также очередь из которой будут, в автоматическом
@@ -15,22 +16,26 @@ import (
 // вспомогательного пользовательского типа.
 func NewCacheStorage[T any](opts ...cacheOptions[T]) (*CacheStorageWithQueue[T], error) {
 	cacheExObj := &CacheStorageWithQueue[T]{
-		//значение по умолчанию для интервала автоматической обработки
-		timeTick: time.Duration(5 * time.Second),
-		//значение по умолчанию для времени жизни объекта
-		maxTtl:  time.Duration(3600 * time.Second),
 		logging: &writeLog{},
+		metrics: newMetricsCollector(),
 		//очередь
 		queue: queueObjects[T]{
-			storages: []CacheStorageHandler[T](nil),
+			storages: []queueItem[T](nil),
 		},
 		cache: cacheStorages[T]{
-			//значение по умолчанию максимального размера кэша
-			maxSize: 15,
-			//основное хранилище
-			storages: map[string]storageParameters[T]{},
+			//по умолчанию кэш состоит из одного сегмента
+			shards: newCacheShards[T](1),
+		},
+		config: configControl{
+			tickChanged: make(chan struct{}, 1),
 		},
 	}
+	//значение по умолчанию для интервала автоматической обработки
+	cacheExObj.timeTick.Store(int64(5 * time.Second))
+	//значение по умолчанию для времени жизни объекта
+	cacheExObj.maxTtl.Store(int64(3600 * time.Second))
+	//значение по умолчанию максимального размера кэша
+	cacheExObj.cache.maxSize.Store(15)
 
 	for _, opt := range opts {
 		if err := opt(cacheExObj); err != nil {
@@ -38,12 +43,63 @@ func NewCacheStorage[T any](opts ...cacheOptions[T]) (*CacheStorageWithQueue[T],
 		}
 	}
 
+	//если структурированное логирование не задано, записи передаются в WriterLoggingData,
+	//как и прежде только ошибки и предупреждения
+	if cacheExObj.logger == nil {
+		cacheExObj.logger = slog.New(NewSlogHandler(cacheExObj.logging, slog.LevelWarn))
+	}
+
+	//наибольший размер кэша зависит от того, где хранятся объекты
+	if err := validateMaxSize(cacheExObj.cache.getMaxSize(), cacheExObj.maxSizeLimit()); err != nil {
+		return cacheExObj, err
+	}
+
+	//при адаптивном управлении выполнение начинается с минимального количества потоков
+	if cacheExObj.adaptive.enabled {
+		if err := validateConcurrency(cacheExObj.cache.getMaxSize(), cacheExObj.adaptive.minLimit); err != nil {
+			return cacheExObj, err
+		}
+
+		isAsync := min(max(cacheExObj.getConcurrency(), cacheExObj.adaptive.minLimit), cacheExObj.adaptive.maxLimit)
+		cacheExObj.isAsync.Store(int64(isAsync))
+	}
+
 	//проверяем количество потоков и размер кэша, если многопоточный режим выполнения
 	//активирован
-	if cacheExObj.isAsync >= 2 {
-		//размер кэша должен как минимум в два раза превышать количество потоков асинхронного выполнения
-		if cacheExObj.cache.maxSize < cacheExObj.isAsync || (cacheExObj.cache.maxSize/cacheExObj.isAsync) < 2 {
-			return cacheExObj, errors.New("the cache size must be at least twice the number of asynchronous execution threads")
+	if err := validateConcurrency(cacheExObj.cache.getMaxSize(), cacheExObj.getConcurrency()); err != nil {
+		return cacheExObj, err
+	}
+
+	//хранилище объектов кэша в файлах, по одному файлу на каждый сегмент кэша
+	if cacheExObj.backend.dir != "" {
+		if err := cacheExObj.openFileBackends(); err != nil {
+			return cacheExObj, err
+		}
+	}
+
+	//автоматическое сохранение состояния хранилища возможно только при заданных
+	//Codec и HandlerFactory, ранее сохраненное состояние восстанавливается из файла
+	//при использовании журнала упреждающей записи состояние восстанавливается из журнала
+	if cacheExObj.snapshot.path != "" {
+		if cacheExObj.snapshot.codec == nil {
+			return cacheExObj, errors.New("automatic saving of the storage state requires the WithSnapshot option")
+		}
+
+		if _, err := os.Stat(cacheExObj.snapshot.path); err == nil && cacheExObj.walOpts.dir == "" {
+			if err := cacheExObj.LoadSnapshotFromFile(cacheExObj.snapshot.path); err != nil {
+				return cacheExObj, err
+			}
+		}
+	}
+
+	//журнал упреждающей записи возможен только при заданных Codec и HandlerFactory
+	if cacheExObj.walOpts.dir != "" {
+		if cacheExObj.snapshot.codec == nil {
+			return cacheExObj, errors.New("the write-ahead log requires the WithSnapshot option")
+		}
+
+		if err := cacheExObj.openWAL(); err != nil {
+			return cacheExObj, err
 		}
 	}
 
@@ -52,8 +108,13 @@ func NewCacheStorage[T any](opts ...cacheOptions[T]) (*CacheStorageWithQueue[T],
 
 // StartAutomaticExecution автоматическая обработка очередей и объектов в кэше
 func (c *CacheStorageWithQueue[T]) StartAutomaticExecution(ctx context.Context) {
+	//периодическое сохранение состояния хранилища в файл
+	if c.snapshot.path != "" {
+		go c.autoSnapshot(ctx)
+	}
+
 	go func() {
-		tick := time.NewTicker(c.timeTick)
+		tick := time.NewTicker(c.getTimeTick())
 		defer tick.Stop()
 
 		for {
@@ -61,19 +122,32 @@ func (c *CacheStorageWithQueue[T]) StartAutomaticExecution(ctx context.Context)
 			case <-ctx.Done():
 				return
 
+			case <-c.config.tickChanged:
+				//интервал изменен методом SetTimeTick
+				tick.Reset(c.getTimeTick())
+
 			case <-tick.C:
 				//поиск и удаление из хранилища всех объектов у которых истекло время жизни
 				c.DeleteForTimeExpiryObjectFromCache()
 
 				//поиск и удаление самого старого объекта если размер кэша достиг максимального значения
 				//выполняется удаление объекта который в настоящее время не выполняеться и ранее был успешно выполнен
-				if c.GetCacheSize() == c.cache.maxSize {
+				if c.GetCacheSize() >= c.cache.getMaxSize() {
 					if err := c.DeleteOldestObjectFromCache(); err != nil {
-						c.logging.Write("error", supportingfunctions.CustomError(fmt.Errorf("cachingstoragewithqueue package: '%s'", err.Error())).Error())
+						c.logError(logEventEvictionFailed, err)
 					}
 				}
 
-				if c.isAsync >= 2 {
+				//сброс журнала упреждающей записи на диск и его сжатие
+				c.maintainWAL()
+
+				//начало очередных запусков периодических объектов
+				c.startRecurringRuns()
+
+				if c.batch.handler != nil {
+					//групповая обработка задач
+					c.batchExecution(ctx)
+				} else if c.getConcurrency() >= 2 {
 					//асинхронная обработка задач
 					c.asyncExecution(ctx)
 				} else {
@@ -85,15 +159,30 @@ func (c *CacheStorageWithQueue[T]) StartAutomaticExecution(ctx context.Context)
 	}()
 }
 
+// Close сбрасывает на диск и закрывает журнал упреждающей записи и освобождает ресурсы
+// хранилища объектов кэша, после вызова Close хранилище использовать нельзя
+func (c *CacheStorageWithQueue[T]) Close() error {
+	var err error
+	if c.wal != nil {
+		err = c.wal.Close()
+	}
+
+	if errClose := c.closeBackends(); err == nil {
+		err = errClose
+	}
+
+	return err
+}
+
 // WithMaxTtl устанавливает максимальное время, по истечении которого запись в cacheStorages будет
 // удалена, допустимый интервал времени хранения записи от 60 до 86400 секунд
 func WithMaxTtl[T any](v int) cacheOptions[T] {
 	return func(cswq *CacheStorageWithQueue[T]) error {
-		if v < 60 || v > 86400 {
-			return errors.New("the maximum time after which an entry in the cache will be deleted should not be less than 300 seconds or more than 24 hours (86400 seconds)")
+		if err := validateMaxTtl(v); err != nil {
+			return err
 		}
 
-		cswq.maxTtl = time.Duration(v) * time.Second
+		cswq.maxTtl.Store(int64(time.Duration(v) * time.Second))
 
 		return nil
 	}
@@ -104,26 +193,29 @@ func WithMaxTtl[T any](v int) cacheOptions[T] {
 // быть в диапазоне от 1 до 120 секунд
 func WithTimeTick[T any](v int) cacheOptions[T] {
 	return func(cswq *CacheStorageWithQueue[T]) error {
-		if v < 1 || v > 120 {
-			return errors.New("the set clock cycle time should not be less than 3 seconds or more than 120 seconds")
+		if err := validateTimeTick(v); err != nil {
+			return err
 		}
 
-		cswq.timeTick = time.Duration(v) * time.Second
+		cswq.timeTick.Store(int64(time.Duration(v) * time.Second))
 
 		return nil
 	}
 }
 
-// WithMaxSize устанавливает максимальный размер кэша, не может быть меньше 3 и больше 1000 хранимых объектов
-// кроме того, размер кэша должен минимум в ДВА раза первышать количество асинхронных потоков
-// выполнения, если асинхронный режим активирован
+// WithMaxSize устанавливает максимальный размер кэша, не может быть меньше 3 и больше 1000 хранимых объектов,
+// или больше 10000000 объектов при использовании опции WithFileBackend, кроме того, размер кэша
+// должен минимум в ДВА раза первышать количество асинхронных потоков выполнения, если асинхронный
+// режим активирован
 func WithMaxSize[T any](v int) cacheOptions[T] {
 	return func(cswq *CacheStorageWithQueue[T]) error {
-		if v < 3 || v > 1000 {
-			return errors.New("the maximum cache size cannot be less than 3 or more than 1000 objects")
+		//окончательно размер проверяется после применения всех опций, так как опция
+		//WithFileBackend может быть задана после WithMaxSize
+		if err := validateMaxSize(v, maxFileBackendSize); err != nil {
+			return err
 		}
 
-		cswq.cache.maxSize = v
+		cswq.cache.maxSize.Store(int64(v))
 
 		return nil
 	}
@@ -140,6 +232,24 @@ func WithLogging[T any](customLogging WriterLoggingData) cacheOptions[T] {
 	}
 }
 
+// WithSlogLogger устанавливает структурированное логирование с помощью log/slog. Записи
+// содержат тип события в поле event, а также, в зависимости от события, поля id, attempt,
+// duration и error. Выполнение функций объектов логируется с уровнем slog.LevelDebug при
+// успешном выполнении и slog.LevelInfo при неуспешном, отклонение объектов с уровнем
+// slog.LevelWarn, ошибки с уровнем slog.LevelError. Если опция задана, обработчик заданный
+// опцией WithLogging не используется
+func WithSlogLogger[T any](logger *slog.Logger) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if logger == nil {
+			return errors.New("the structured logger is not set")
+		}
+
+		cswq.logger = logger
+
+		return nil
+	}
+}
+
 // WithEnableAsyncProcessing устанавливает асинхронное выполнение функций в кэша, при этом
 // асинхронное выполнение будет активировано только если количество потоков, заданных
 // через эту функцию, будут два и более. Максимальное количество потоков должно быть меньше
@@ -147,8 +257,278 @@ func WithLogging[T any](customLogging WriterLoggingData) cacheOptions[T] {
 // быть меньше 8
 func WithEnableAsyncProcessing[T any](numberStreams int) cacheOptions[T] {
 	return func(cswq *CacheStorageWithQueue[T]) error {
-		cswq.isAsync = numberStreams
+		cswq.isAsync.Store(int64(numberStreams))
+
+		return nil
+	}
+}
+
+// WithAdaptiveConcurrency включает адаптивное управление количеством одновременно
+// выполняемых функций по алгоритму AIMD. Количество увеличивается на 1, пока функции
+// выполняются успешно и быстрее targetLatencyMs миллисекунд, и уменьшается в 0.9 раза при
+// неуспешном или более долгом выполнении, оставаясь в пределах от minLimit до maxLimit.
+// Значение minLimit должно быть не меньше 2, maxLimit не больше 500, а размер кэша как
+// минимум в два раза превышать текущее количество. Текущее количество возвращает Stats,
+// максимальное количество можно изменить методом SetConcurrency
+func WithAdaptiveConcurrency[T any](minLimit, maxLimit, targetLatencyMs int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if minLimit < 2 || maxLimit > 500 || minLimit > maxLimit {
+			return errors.New("the limits of the number of concurrently running functions must be from 2 to 500 and the minimum limit must not exceed the maximum one")
+		}
+
+		if targetLatencyMs < 1 || targetLatencyMs > 3600000 {
+			return errors.New("the target execution time should not be less than 1 millisecond or more than 1 hour (3600000 milliseconds)")
+		}
+
+		cswq.adaptive = adaptiveConcurrency{
+			enabled:       true,
+			minLimit:      minLimit,
+			maxLimit:      maxLimit,
+			targetLatency: time.Duration(targetLatencyMs) * time.Millisecond,
+		}
+
+		return nil
+	}
+}
+
+// WithShards разделяет кэш на заданное количество сегментов (shards) по хешу ключа объекта,
+// у каждого сегмента своя блокировка, что снижает конкуренцию за блокировку при большом
+// количестве одновременных операций с кэшем. Количество сегментов должно быть в диапазоне
+// от 1 до 256, значение по умолчанию 1
+func WithShards[T any](v int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if v < 1 || v > 256 {
+			return errors.New("the number of cache shards cannot be less than 1 or more than 256")
+		}
+
+		cswq.cache.shards = newCacheShards[T](v)
+
+		return nil
+	}
+}
+
+// WithObjectHistory включает хранение предыдущих версий объектов, заменяемых в кэше при
+// поступлении объекта с тем же ключом но другим содержимым. Для каждого ключа хранится
+// не более size последних версий, допустимый диапазон от 1 до 100. Если T является указателем,
+// а метод MatchingAndReplacement изменяет объект из кэша, а не создает новый, необходимо
+// передать функцию clone копирующую объект, иначе может быть nil. Версии сохраняются в
+// снимке состояния и в журнале упреждающей записи
+func WithObjectHistory[T any](size int, clone func(T) T) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if size < 1 || size > 100 {
+			return errors.New("the number of stored object versions cannot be less than 1 or more than 100")
+		}
+
+		cswq.history = historyOptions[T]{size: size, clone: clone}
+
+		return nil
+	}
+}
+
+// WithDedupWindow включает окно дедупликации, в котором после удаления из кэша хранятся ключи
+// успешно выполненных объектов. Объект, ключ которого есть в окне, в кэш не добавляется и
+// повторно не выполняется. Ключ хранится ttl секунд, допустимый интервал от 60 до 2592000
+// секунд (30 суток). Максимальное количество хранимых ключей задается maxSize, в диапазоне
+// от 1 до 10000000, при его превышении удаляются самые старые ключи
+func WithDedupWindow[T any](ttl, maxSize int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if ttl < 60 || ttl > 2592000 {
+			return errors.New("the lifetime of keys in the deduplication window should not be less than 60 seconds or more than 30 days (2592000 seconds)")
+		}
+
+		if maxSize < 1 || maxSize > 10000000 {
+			return errors.New("the size of the deduplication window cannot be less than 1 or more than 10000000 keys")
+		}
+
+		cswq.dedup = newDedupTombstones(time.Duration(ttl)*time.Second, maxSize)
+
+		return nil
+	}
+}
+
+// WithDedupBloomFilter включает окно дедупликации на основе фильтров Блума, которое, в отличии
+// от WithDedupWindow, требует фиксированного объема памяти при большом количестве ключей, но
+// с вероятностью falsePositiveRate, в диапазоне от 0 до 0.5, может принять новый объект за
+// уже выполненный. Ключ хранится до ttl секунд, допустимый интервал от 60 до 2592000
+// секунд (30 суток). Объем фильтра расчитывается на expectedItems ключей за время ttl, в
+// диапазоне от 1 до 100000000. Если за время ttl запоминается больше expectedItems ключей,
+// окно сокращается и ключ удаляется раньше ttl, после того как за ним будет запомнено
+// от expectedItems до двух expectedItems ключей, поэтому expectedItems должно быть не
+// меньше количества ключей, запоминаемых за время ttl
+func WithDedupBloomFilter[T any](ttl, expectedItems int, falsePositiveRate float64) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if ttl < 60 || ttl > 2592000 {
+			return errors.New("the lifetime of keys in the deduplication window should not be less than 60 seconds or more than 30 days (2592000 seconds)")
+		}
+
+		if expectedItems < 1 || expectedItems > 100000000 {
+			return errors.New("the expected number of keys in the deduplication window cannot be less than 1 or more than 100000000")
+		}
+
+		if falsePositiveRate <= 0 || falsePositiveRate >= 0.5 {
+			return errors.New("the false positive rate of the deduplication window must be greater than 0 and less than 0.5")
+		}
+
+		cswq.dedup = newDedupBloom(time.Duration(ttl)*time.Second, expectedItems, falsePositiveRate)
+
+		return nil
+	}
+}
+
+// WithBatchHandler включает групповое выполнение объектов. Ожидающие выполнения объекты
+// группируются, не более maxBatchSize объектов в группе, от 1 до 1000, и передаются
+// обработчику handler одним вызовом, после чего успешность выполнения каждого объекта
+// определяется по возвращённой для него ошибке. Неполная группа выполняется, если самый
+// старый объект группы ожидает дольше linger секунд, от 0 до 3600. Одновременно выполняется
+// не более одной группы, функции объектов и асинхронное выполнение при этом не используются
+func WithBatchHandler[T any](handler BatchHandlerFunc[T], maxBatchSize, linger int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if handler == nil {
+			return errors.New("the batch handler is not set")
+		}
+
+		if maxBatchSize < 1 || maxBatchSize > 1000 {
+			return errors.New("the maximum batch size cannot be less than 1 or more than 1000 objects")
+		}
+
+		if linger < 0 || linger > 3600 {
+			return errors.New("the batch linger time should not be less than 0 seconds or more than 1 hour (3600 seconds)")
+		}
+
+		cswq.batch.handler = handler
+		cswq.batch.maxSize = maxBatchSize
+		cswq.batch.linger = time.Duration(linger) * time.Second
+
+		return nil
+	}
+}
+
+// WithDeadLetterQueue включает очередь недоставленных объектов, в которую при удалении из
+// кэша, по истечении времени жизни или при превышении размера кэша, перемещаются объекты,
+// функции которых выполнялись, но не были выполнены успешно. Объект хранится
+// ttl секунд, допустимый интервал от 60 до 2592000 секунд (30 дней), максимальный размер
+// очереди maxSize от 1 до 100000 объектов, при его превышении удаляются самые старые объекты
+func WithDeadLetterQueue[T any](ttl, maxSize int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if ttl < 60 || ttl > 2592000 {
+			return errors.New("the lifetime of objects in the dead letter queue should not be less than 60 seconds or more than 30 days (2592000 seconds)")
+		}
+
+		if maxSize < 1 || maxSize > 100000 {
+			return errors.New("the size of the dead letter queue cannot be less than 1 or more than 100000 objects")
+		}
+
+		cswq.dead = newDeadLetterQueue[T](time.Duration(ttl)*time.Second, maxSize)
+
+		return nil
+	}
+}
+
+// WithSnapshot задает Codec, для кодирования объектов при сохранении состояния хранилища
+// методом SaveSnapshot, и HandlerFactory, для восстановления вспомогательных типов и их
+// функций-обёрток выполнения методом LoadSnapshot
+func WithSnapshot[T any](codec Codec[T], factory HandlerFactory[T]) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if codec == nil || factory == nil {
+			return errors.New("the codec and the handler factory must be set to save the storage state")
+		}
+
+		cswq.snapshot.codec = codec
+		cswq.snapshot.factory = factory
+
+		return nil
+	}
+}
+
+// WithAutoSnapshot включает автоматическое сохранение состояния хранилища в файл path с
+// интервалом interval, в диапазоне от 1 до 86400 секунд, а также при завершении контекста
+// переданного в StartAutomaticExecution. Если файл существует, состояние хранилища
+// восстанавливается из него при создании хранилища. Требует опцию WithSnapshot
+func WithAutoSnapshot[T any](path string, interval int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if path == "" {
+			return errors.New("the file for automatic saving of the storage state is not set")
+		}
+
+		if interval < 1 || interval > 86400 {
+			return errors.New("the interval for automatic saving of the storage state should not be less than 1 second or more than 24 hours (86400 seconds)")
+		}
+
+		cswq.snapshot.path = path
+		cswq.snapshot.interval = time.Duration(interval) * time.Second
+
+		return nil
+	}
+}
+
+// WithWAL включает журнал упреждающей записи в директории dir. В журнал записываются
+// добавление объектов в очередь, их добавление в кэш и завершение выполнения, при создании
+// хранилища очередь и кэш восстанавливаются из журнала, если восстанавливаемый кэш больше
+// максимального размера кэша, создание хранилища завершается ошибкой. Размер сегмента журнала
+// segmentSize задается в мегабайтах, в диапазоне от 1 до 1024. Требует опцию WithSnapshot
+func WithWAL[T any](dir string, fsync WALFsyncPolicy, segmentSize int) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if dir == "" {
+			return errors.New("the directory of the write-ahead log is not set")
+		}
+
+		if segmentSize < 1 || segmentSize > 1024 {
+			return errors.New("the size of the write-ahead log segment should not be less than 1 megabyte or more than 1024 megabytes")
+		}
+
+		var policy wal.FsyncPolicy
+		switch fsync {
+		case WALFsyncAlways:
+			policy = wal.FsyncAlways
+		case WALFsyncEverySecond:
+			policy = wal.FsyncInterval
+		case WALFsyncNever:
+			policy = wal.FsyncNever
+		default:
+			return errors.New("unknown fsync policy of the write-ahead log")
+		}
+
+		cswq.walOpts = walOptions{
+			dir:         dir,
+			policy:      policy,
+			segmentSize: int64(segmentSize) << 20,
+		}
+
+		return nil
+	}
+}
+
+// WithFileBackend хранит объекты кэша, их предыдущие версии и результаты выполнения функций
+// в файлах в директории dir, по одному файлу на каждый сегмент кэша, в памяти остаются
+// ключи, индексы по времени истечения жизни, статус выполнения и другие параметры объектов
+// и функции-обёртки выполнения. Используется для кэша, объем объектов
+// которого превышает объем оперативной памяти, максимальный размер такого кэша может
+// достигать 10000000 объектов. Объекты кодируются с помощью codec. Файлы являются
+// временным хранилищем: они очищаются при создании хранилища и удаляются методом Close,
+// поэтому объекты из них между перезапусками не восстанавливаются, для сохранения
+// состояния между перезапусками используются опции WithSnapshot и WithWAL
+func WithFileBackend[T any](dir string, codec Codec[T]) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		if dir == "" {
+			return errors.New("the directory of the cache files is not set")
+		}
+
+		if codec == nil {
+			return errors.New("the codec for storing cache objects in files is not set")
+		}
+
+		cswq.backend.dir = dir
+		cswq.backend.codec = codec
 
 		return nil
 	}
 }
+
+// WithHandler регистрирует обработчик объектов под именем name, аналогично методу
+// RegisterHandler. Объекты, вспомогательный тип которых реализует интерфейс NamedHandler,
+// выполняются зарегистрированным обработчиком вместо функции заданной через SetFunc
+func WithHandler[T any](name string, handler HandlerFunc[T]) cacheOptions[T] {
+	return func(cswq *CacheStorageWithQueue[T]) error {
+		return cswq.RegisterHandler(name, handler)
+	}
+}
@@ -0,0 +1,122 @@
+package cachingstoragewithqueue
+
+import (
+	"container/heap"
+	"iter"
+	"slices"
+	"time"
+)
+
+// PushObjectToQueueAt добавляет в очередь объект, который не будет выполнен раньше заданного
+// времени. До наступления этого времени объект ожидает в очереди, не добавляется в кэш и
+// не занимает место среди одновременно выполняемых функций, после наступления времени
+// объект становится доступным для обработки в порядке общей очереди
+func (c *CacheStorageWithQueue[T]) PushObjectToQueueAt(v CacheStorageHandler[T], notBefore time.Time) {
+	c.pushObjectToQueue(v, notBefore)
+}
+
+// PushObjectToQueueAfter добавляет в очередь объект, который не будет выполнен раньше
+// чем через заданный интервал времени, аналогично PushObjectToQueueAt
+func (c *CacheStorageWithQueue[T]) PushObjectToQueueAfter(v CacheStorageHandler[T], delay time.Duration) {
+	c.pushObjectToQueue(v, time.Now().Add(delay))
+}
+
+// GetSizeDelayedObjectToQueue количество объектов в очереди время выполнения которых еще не наступило
+func (c *CacheStorageWithQueue[T]) GetSizeDelayedObjectToQueue() int {
+	c.queue.mutex.RLock()
+	defer c.queue.mutex.RUnlock()
+
+	return len(c.queue.delayed)
+}
+
+// pushObjectToQueue добавляет объект в очередь, объекты с не наступившим временем
+// выполнения добавляются в список отложенных объектов
+func (c *CacheStorageWithQueue[T]) pushObjectToQueue(v CacheStorageHandler[T], notBefore time.Time) {
+	c.queue.mutex.Lock()
+	defer c.queue.mutex.Unlock()
+
+	item := queueItem[T]{handler: v, timePush: time.Now(), notBefore: notBefore}
+	c.queue.push(item)
+	c.stats.pushed.Add(1)
+	c.walPush(item)
+}
+
+// push добавляет объект в очередь, блокировка очереди должна быть выполнена вызывающей стороной
+func (q *queueObjects[T]) push(item queueItem[T]) {
+	if item.notBefore.After(time.Now()) {
+		heap.Push(&q.delayed, item)
+
+		return
+	}
+
+	q.storages = append(q.storages, item)
+}
+
+// promote переносит в конец очереди отложенные объекты, время выполнения которых наступило,
+// блокировка очереди должна быть выполнена вызывающей стороной
+func (q *queueObjects[T]) promote(now time.Time) {
+	for len(q.delayed) > 0 && !q.delayed[0].notBefore.After(now) {
+		q.storages = append(q.storages, heap.Pop(&q.delayed).(queueItem[T]))
+	}
+}
+
+// len общее количество объектов в очереди, включая отложенные
+func (q *queueObjects[T]) len() int {
+	return len(q.storages) + len(q.delayed)
+}
+
+// all перебор всех объектов очереди, сначала доступных для обработки, затем отложенных
+func (q *queueObjects[T]) all() iter.Seq[queueItem[T]] {
+	return func(yield func(queueItem[T]) bool) {
+		for _, item := range q.storages {
+			if !yield(item) {
+				return
+			}
+		}
+
+		for _, item := range q.delayed {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// deleteFunc удаляет из очереди, включая отложенные объекты, все объекты для которых f
+// возвращает true
+func (q *queueObjects[T]) deleteFunc(f func(queueItem[T]) bool) {
+	q.storages = slices.DeleteFunc(q.storages, f)
+	q.delayed = slices.DeleteFunc(q.delayed, f)
+	heap.Init(&q.delayed)
+}
+
+// clean очистка очереди, включая отложенные объекты
+func (q *queueObjects[T]) clean() {
+	q.storages = []queueItem[T](nil)
+	q.delayed = delayedQueue[T](nil)
+}
+
+// реализация heap.Interface, объекты упорядочены по времени, раньше которого они не
+// могут быть выполнены, а при совпадении времени по времени добавления в очередь
+func (dq delayedQueue[T]) Len() int { return len(dq) }
+
+func (dq delayedQueue[T]) Less(i, j int) bool {
+	if dq[i].notBefore.Equal(dq[j].notBefore) {
+		return dq[i].timePush.Before(dq[j].timePush)
+	}
+
+	return dq[i].notBefore.Before(dq[j].notBefore)
+}
+
+func (dq delayedQueue[T]) Swap(i, j int) { dq[i], dq[j] = dq[j], dq[i] }
+
+func (dq *delayedQueue[T]) Push(x any) { *dq = append(*dq, x.(queueItem[T])) }
+
+func (dq *delayedQueue[T]) Pop() any {
+	old := *dq
+	n := len(old)
+	item := old[n-1]
+	*dq = old[:n-1]
+
+	return item
+}
@@ -0,0 +1,151 @@
+package cachingstoragewithqueue
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/cron"
+)
+
+// AddRecurringObject добавляет в кэш периодический объект, функция которого выполняется
+// через равные интервалы времени, интервал не может быть меньше 1 секунды. Подробнее
+// в описании AddCronObject
+func (c *CacheStorageWithQueue[T]) AddRecurringObject(v CacheStorageHandler[T], interval time.Duration) error {
+	schedule, err := cron.Every(interval)
+	if err != nil {
+		return err
+	}
+
+	return c.addRecurringObject(v, schedule)
+}
+
+// AddCronObject добавляет в кэш периодический объект, функция которого выполняется по
+// расписанию заданному выражением cron из пяти полей (минуты, часы, день месяца, месяц,
+// день недели), сокращенным выражением (@hourly, @daily, @weekly, @monthly, @yearly)
+// или интервалом вида '@every 1h30m'. Первый запуск выполняется в ближайшее время по
+// расписанию. Во время каждого запуска количество попыток выполнения функции считается
+// заново, запуск завершается после успешного выполнения функции или после всех попыток
+// выполнения, а время следующего запуска отсчитывается от завершения запуска, поэтому
+// запуски одного объекта не пересекаются. Периодические объекты занимают место в кэше,
+// но не удаляются по истечении времени жизни и при достижении максимального размера
+// кэша, удалить такой объект можно методом Cancel. При достижении максимального размера
+// кэша, как и при добавлении объектов из очереди, удаляется самый старый объект. Возвращает
+// *EntryError с ErrWrongState, если объект с таким ключом уже находится в кэше, или с
+// ErrCacheFull, если кэш достиг максимального размера и удалить объект нельзя
+func (c *CacheStorageWithQueue[T]) AddCronObject(v CacheStorageHandler[T], spec string) error {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	return c.addRecurringObject(v, schedule)
+}
+
+// addRecurringObject добавляет в кэш периодический объект с заданным расписанием
+func (c *CacheStorageWithQueue[T]) addRecurringObject(v CacheStorageHandler[T], schedule *cron.Schedule) error {
+	const op = "add recurring"
+
+	key := v.GetID()
+	now := time.Now()
+	timeNextRun := schedule.Next(now)
+	if timeNextRun.IsZero() {
+		return fmt.Errorf("the schedule '%s' of the object with key ID '%s' has no upcoming run time", schedule, key)
+	}
+
+	//при достижении максимального размера кэша удаляется самый старый объект, периодические
+	//объекты не удаляются, поэтому кэш может остаться заполненным
+	if c.GetCacheSize() >= c.cache.getMaxSize() {
+		if err := c.DeleteOldestObjectFromCache(); err != nil {
+			return &EntryError{Op: op, Key: key, Err: fmt.Errorf("%w: %v", ErrCacheFull, err)}
+		}
+
+		if c.GetCacheSize() >= c.cache.getMaxSize() {
+			return &EntryError{Op: op, Key: key, Err: ErrCacheFull}
+		}
+	}
+
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	if _, ok := sh.storages.get(key); ok {
+		sh.mutex.Unlock()
+
+		return &EntryError{Op: op, Key: key, Err: fmt.Errorf("%w, the object is already in the cache", ErrWrongState)}
+	}
+
+	storage := storageParameters[T]{
+		timeMain:       now,
+		originalObject: v.GetObject(),
+		cacheFunc:      c.objectFunc(key, v),
+		handlerName:    getHandlerName(v),
+		hash:           getHash(v),
+		schedule:       schedule,
+		timeNextRun:    timeNextRun,
+	}
+	sh.set(key, storage)
+	c.stats.admitted.Add(1)
+	c.walStore(walOpState, key, storage)
+	sh.mutex.Unlock()
+
+	c.emit(newEvent(EventAdmitted, key, storage.originalObject, ReasonNew))
+
+	return nil
+}
+
+// startRecurringRuns начинает очередные запуски периодических объектов, время которых
+// наступило, количество попыток и результат выполнения функции объекта сбрасываются,
+// а объект становится доступным для выполнения
+func (c *CacheStorageWithQueue[T]) startRecurringRuns() {
+	now := time.Now()
+	for _, sh := range c.cache.shards {
+		sh.mutex.Lock()
+		for {
+			key, timeNextRun, ok := sh.recurring.peek()
+			if !ok || timeNextRun.After(now) {
+				break
+			}
+
+			storage, ok := sh.storages.get(key)
+			if !ok {
+				sh.recurring.remove(key)
+
+				continue
+			}
+
+			storage.timeNextRun = time.Time{}
+			storage.numberExecutionAttempts = 0
+			storage.isCompletedSuccessfully = false
+			storage.lastError = nil
+			sh.set(key, storage)
+			c.walStore(walOpState, key, storage)
+		}
+		sh.mutex.Unlock()
+	}
+}
+
+// finishRecurringRun завершает запуск периодического объекта после успешного выполнения
+// функции или после всех попыток выполнения и устанавливает время следующего запуска
+func finishRecurringRun[T any](storage *storageParameters[T]) {
+	if storage.schedule == nil {
+		return
+	}
+
+	if !storage.isCompletedSuccessfully && storage.numberExecutionAttempts < maxExecutionAttempts {
+		return
+	}
+
+	storage.timeNextRun = storage.schedule.Next(time.Now())
+}
+
+// parseSchedule восстанавливает расписание периодического объекта, для остальных объектов nil
+func parseSchedule(key, spec string) (*cron.Schedule, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring the schedule of the object with key ID '%s': %w", key, err)
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,88 @@
+package cachingstoragewithqueue
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GetResult возвращает результат последнего выполнения функции объекта находящегося в кэше,
+// если вспомогательный тип объекта реализует интерфейс ResultHandler. Возвращает false, если
+// объекта нет в кэше или функция объекта не вернула результат. Результат восстановленный
+// из снимка состояния, журнала упреждающей записи или файлового хранилища представлен в
+// виде json.RawMessage, для получения результата заданного типа можно использовать GetResultAs
+func (c *CacheStorageWithQueue[T]) GetResult(key string) (any, bool) {
+	storage, ok := c.getStorageParameters(key)
+	if !ok || storage.result == nil {
+		return nil, false
+	}
+
+	return storage.result, true
+}
+
+// GetResultAs возвращает результат последнего выполнения функции объекта находящегося в
+// кэше приведенный к типу R, результат в виде json.RawMessage декодируется в тип R.
+// Возвращает false, если результата нет или он не может быть приведен к типу R
+func GetResultAs[R, T any](c *CacheStorageWithQueue[T], key string) (R, bool) {
+	var v R
+
+	result, ok := c.GetResult(key)
+	if !ok {
+		return v, false
+	}
+
+	if v, ok := result.(R); ok {
+		return v, true
+	}
+
+	raw, ok := result.(json.RawMessage)
+	if !ok {
+		return v, false
+	}
+
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, false
+	}
+
+	return v, true
+}
+
+// storeResult сохраняет результат выполнения функции объекта для запуска с номером
+// generation, результат запуска, номер которого не совпадает с номером последнего
+// запуска объекта, отбрасывается
+func (c *CacheStorageWithQueue[T]) storeResult(key string, generation uint64, result any) {
+	sh := c.cache.shard(key)
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	storage, ok := sh.storages.get(key)
+	if !ok || (generation != 0 && storage.generation != generation) {
+		return
+	}
+
+	storage.result = result
+	sh.set(key, storage)
+}
+
+// encodeResult кодирует результат выполнения функции объекта в JSON
+func encodeResult(key string, result any) (json.RawMessage, error) {
+	if result == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the result of the cache object with key ID '%s': %w", key, err)
+	}
+
+	return b, nil
+}
+
+// decodeResult восстанавливает результат выполнения функции объекта, результат остается
+// в виде json.RawMessage, так как его тип неизвестен
+func decodeResult(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+
+	return b
+}
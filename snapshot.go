@@ -0,0 +1,368 @@
+package cachingstoragewithqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// версия формата снимка состояния хранилища
+const snapshotVersion = 1
+
+// snapshotData снимок состояния хранилища
+type snapshotData struct {
+	Created time.Time           `json:"created"`
+	Queue   []snapshotQueueItem `json:"queue"`
+	Cache   []snapshotCacheItem `json:"cache"`
+	Version int                 `json:"version"`
+}
+
+// snapshotQueueItem объект из очереди
+type snapshotQueueItem struct {
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	ID        string     `json:"id"`
+	Object    []byte     `json:"object"`
+}
+
+// snapshotCacheItem объект из кэша
+type snapshotCacheItem struct {
+	TimeMain                time.Time             `json:"time_main"`
+	TimeExpiry              time.Time             `json:"time_expiry"`
+	TimeNextRun             *time.Time            `json:"time_next_run,omitempty"`
+	ID                      string                `json:"id"`
+	HandlerName             string                `json:"handler_name,omitempty"`
+	Schedule                string                `json:"schedule,omitempty"`
+	LastError               string                `json:"last_error,omitempty"`
+	Result                  json.RawMessage       `json:"result,omitempty"`
+	Object                  []byte                `json:"object"`
+	Hash                    []byte                `json:"hash,omitempty"`
+	History                 []snapshotVersionItem `json:"history,omitempty"`
+	NumberExecutionAttempts int                   `json:"number_execution_attempts"`
+	IsCompletedSuccessfully bool                  `json:"is_completed_successfully"`
+}
+
+// snapshotVersionItem предыдущая версия объекта
+type snapshotVersionItem struct {
+	Time   time.Time `json:"time"`
+	Object []byte    `json:"object"`
+}
+
+// SaveSnapshot сохраняет состояние очереди и кэша. Объекты кодируются с помощью Codec,
+// заданного опцией WithSnapshot, функции-обёртки выполнения не сохраняются. Объекты,
+// функции которых выполняются в момент сохранения, сохраняются как невыполняющиеся.
+// Окно дедупликации, заданное опциями WithDedupWindow или WithDedupBloomFilter, не
+// сохраняется, поэтому после восстановления объекты с ключами удалённых из кэша успешно
+// выполненных объектов снова добавляются в кэш и выполняются
+func (c *CacheStorageWithQueue[T]) SaveSnapshot(w io.Writer) error {
+	if c.snapshot.codec == nil {
+		return errors.New("the codec for saving the storage state is not set, use the WithSnapshot option")
+	}
+
+	queue, cache := c.copyState()
+	data := snapshotData{
+		Version: snapshotVersion,
+		Created: time.Now(),
+		Queue:   make([]snapshotQueueItem, 0, len(queue)),
+		Cache:   make([]snapshotCacheItem, 0, len(cache)),
+	}
+
+	for _, v := range queue {
+		b, err := c.snapshot.codec.Encode(v.handler.GetObject())
+		if err != nil {
+			return fmt.Errorf("error encoding a queue object with key ID '%s': %w", v.handler.GetID(), err)
+		}
+
+		data.Queue = append(data.Queue, snapshotQueueItem{ID: v.handler.GetID(), Object: b, NotBefore: optionalTime(v.notBefore)})
+	}
+
+	for key, storage := range cache {
+		item, err := encodeCacheItem(c.snapshot.codec, key, storage)
+		if err != nil {
+			return err
+		}
+
+		data.Cache = append(data.Cache, item)
+	}
+
+	return json.NewEncoder(w).Encode(data)
+}
+
+// LoadSnapshot восстанавливает состояние очереди и кэша сохраненное SaveSnapshot. Объекты
+// из снимка добавляются в конец очереди и в кэш, объекты кэша с такими же ключами
+// заменяются, отложенные объекты очереди сохраняют время, раньше которого они не
+// выполняются. Функции-обёртки выполнения восстанавливаются с помощью HandlerFactory,
+// заданной опцией WithSnapshot. Функции объектов кэша, которые не были выполнены успешно,
+// выполняются снова раньше остальных объектов. Если после восстановления количество объектов в кэше
+// превысит максимальный размер кэша, состояние не восстанавливается и возвращается ошибка.
+// Метод нужно вызывать до запуска автоматической обработки
+func (c *CacheStorageWithQueue[T]) LoadSnapshot(r io.Reader) error {
+	if c.snapshot.codec == nil || c.snapshot.factory == nil {
+		return errors.New("the codec or handler factory for restoring the storage state is not set, use the WithSnapshot option")
+	}
+
+	var data snapshotData
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("error decoding the storage state: %w", err)
+	}
+
+	if data.Version != snapshotVersion {
+		return fmt.Errorf("unsupported version '%d' of the storage state", data.Version)
+	}
+
+	queue := make([]queueItem[T], 0, len(data.Queue))
+	for _, v := range data.Queue {
+		obj, err := c.snapshot.codec.Decode(v.Object)
+		if err != nil {
+			return fmt.Errorf("error decoding a queue object with key ID '%s': %w", v.ID, err)
+		}
+
+		queue = append(queue, queueItem[T]{handler: c.snapshot.factory(v.ID, obj), timePush: time.Now(), notBefore: timeOrZero(v.NotBefore)})
+	}
+
+	cache := make(map[string]storageParameters[T], len(data.Cache))
+	for _, v := range data.Cache {
+		storage, err := decodeCacheItem(c.snapshot.codec, v)
+		if err != nil {
+			return err
+		}
+		storage.cacheFunc = c.objectFunc(v.ID, c.snapshot.factory(v.ID, storage.originalObject))
+
+		cache[v.ID] = storage
+	}
+
+	c.cache.lockAll()
+	size := c.cache.size()
+	for key := range cache {
+		if _, ok := c.cache.shard(key).storages.get(key); !ok {
+			size++
+		}
+	}
+	if size > c.cache.getMaxSize() {
+		c.cache.unlockAll()
+
+		return fmt.Errorf("the restored cache would contain %d objects, which exceeds the maximum cache size %d", size, c.cache.getMaxSize())
+	}
+
+	for key, storage := range cache {
+		c.cache.shard(key).setRestored(key, storage)
+	}
+	c.cache.unlockAll()
+
+	c.queue.mutex.Lock()
+	for _, item := range queue {
+		c.queue.push(item)
+	}
+	c.queue.mutex.Unlock()
+
+	//восстановленное состояние фиксируется в журнале упреждающей записи
+	if c.wal != nil {
+		return c.CompactWAL()
+	}
+
+	return nil
+}
+
+// copyState копирует содержимое очереди и кэша, блокировки удерживаются только
+// на время копирования
+func (c *CacheStorageWithQueue[T]) copyState() ([]queueItem[T], map[string]storageParameters[T]) {
+	c.queue.mutex.RLock()
+	defer c.queue.mutex.RUnlock()
+
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
+
+	return c.copyStateLocked()
+}
+
+// copyStateLocked копирует содержимое очереди и кэша, блокировка очереди и всех
+// сегментов кэша должна быть выполнена вызывающей стороной
+func (c *CacheStorageWithQueue[T]) copyStateLocked() ([]queueItem[T], map[string]storageParameters[T]) {
+	queue := make([]queueItem[T], 0, c.queue.len())
+	for item := range c.queue.all() {
+		queue = append(queue, item)
+	}
+
+	cache := make(map[string]storageParameters[T], c.cache.size())
+	for _, sh := range c.cache.shards {
+		for key, storage := range sh.storages.all() {
+			cache[key] = storage
+		}
+	}
+
+	return queue, cache
+}
+
+// encodeCacheItem кодирует параметры объекта из кэша, функция-обёртка выполнения не кодируется
+func encodeCacheItem[T any](codec Codec[T], key string, storage storageParameters[T]) (snapshotCacheItem, error) {
+	b, err := codec.Encode(storage.originalObject)
+	if err != nil {
+		return snapshotCacheItem{}, fmt.Errorf("error encoding a cache object with key ID '%s': %w", key, err)
+	}
+
+	item := snapshotCacheItem{
+		ID:                      key,
+		HandlerName:             storage.handlerName,
+		Object:                  b,
+		Hash:                    storage.hash,
+		TimeMain:                storage.timeMain,
+		TimeExpiry:              storage.timeExpiry,
+		TimeNextRun:             optionalTime(storage.timeNextRun),
+		Schedule:                storage.schedule.String(),
+		NumberExecutionAttempts: storage.numberExecutionAttempts,
+		IsCompletedSuccessfully: storage.isCompletedSuccessfully,
+	}
+
+	if storage.lastError != nil {
+		item.LastError = storage.lastError.Error()
+	}
+
+	if item.Result, err = encodeResult(key, storage.result); err != nil {
+		return snapshotCacheItem{}, err
+	}
+
+	if item.History, err = encodeHistory(codec, key, storage.history); err != nil {
+		return snapshotCacheItem{}, err
+	}
+
+	return item, nil
+}
+
+// decodeCacheItem декодирует параметры объекта из кэша, функция-обёртка выполнения
+// должна быть установлена вызывающей стороной
+func decodeCacheItem[T any](codec Codec[T], item snapshotCacheItem) (storageParameters[T], error) {
+	obj, err := codec.Decode(item.Object)
+	if err != nil {
+		return storageParameters[T]{}, fmt.Errorf("error decoding a cache object with key ID '%s': %w", item.ID, err)
+	}
+
+	schedule, err := parseSchedule(item.ID, item.Schedule)
+	if err != nil {
+		return storageParameters[T]{}, err
+	}
+
+	storage := storageParameters[T]{
+		originalObject:          obj,
+		handlerName:             item.HandlerName,
+		hash:                    item.Hash,
+		timeMain:                item.TimeMain,
+		timeExpiry:              item.TimeExpiry,
+		timeNextRun:             timeOrZero(item.TimeNextRun),
+		schedule:                schedule,
+		result:                  decodeResult(item.Result),
+		numberExecutionAttempts: item.NumberExecutionAttempts,
+		isCompletedSuccessfully: item.IsCompletedSuccessfully,
+	}
+
+	if item.LastError != "" {
+		storage.lastError = errors.New(item.LastError)
+	}
+
+	if storage.history, err = decodeHistory(codec, item.ID, item.History); err != nil {
+		return storageParameters[T]{}, err
+	}
+
+	return storage, nil
+}
+
+// optionalTime время для необязательного поля записи, для нулевого времени nil, поэтому
+// поле с тегом omitempty не записывается
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// timeOrZero время необязательного поля записи, для отсутствующего поля нулевое время
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
+
+// SaveSnapshotToFile сохраняет состояние очереди и кэша в файл, запись выполняется
+// во временный файл, который затем переименовывается, поэтому при сбое во время
+// записи ранее сохраненное состояние не теряется
+func (c *CacheStorageWithQueue[T]) SaveSnapshotToFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := c.SaveSnapshot(f); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// LoadSnapshotFromFile восстанавливает состояние очереди и кэша из файла
+func (c *CacheStorageWithQueue[T]) LoadSnapshotFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.LoadSnapshot(f)
+}
+
+// autoSnapshot периодическое сохранение состояния хранилища в файл, при завершении
+// контекста состояние сохраняется последний раз
+func (c *CacheStorageWithQueue[T]) autoSnapshot(ctx context.Context) {
+	tick := time.NewTicker(c.snapshot.interval)
+	defer tick.Stop()
+
+	save := func() {
+		if err := c.SaveSnapshotToFile(c.snapshot.path); err != nil {
+			c.logError(logEventSnapshotError, err, slog.String("path", c.snapshot.path))
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			save()
+
+			return
+
+		case <-tick.C:
+			save()
+		}
+	}
+}
+
+// Encode кодирует объект в JSON
+func (JSONCodec[T]) Encode(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode декодирует объект из JSON
+func (JSONCodec[T]) Decode(b []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+
+	return v, err
+}
@@ -0,0 +1,49 @@
+package cachingstoragewithqueue
+
+// Stats возвращает статистику работы хранилища. Счетчики накапливаются с момента
+// создания хранилища, длина очереди, размер кэша и количество выполняющихся функций
+// соответствуют моменту запроса
+func (c *CacheStorageWithQueue[T]) Stats() Stats {
+	stats := Stats{
+		Pushed:                 c.stats.pushed.Load(),
+		Admitted:               c.stats.admitted.Load(),
+		DuplicatesRejected:     c.stats.duplicatesRejected.Load(),
+		Replaced:               c.stats.replaced.Load(),
+		Executed:               c.stats.executed.Load(),
+		Succeeded:              c.stats.succeeded.Load(),
+		Failed:                 c.stats.failed.Load(),
+		EvictedByTtl:           c.stats.evictedByTtl.Load(),
+		EvictedBySize:          c.stats.evictedBySize.Load(),
+		EventsDropped:          c.stats.eventsDropped.Load(),
+		HashDuplicates:         c.stats.hashDuplicates.Load(),
+		HashComparisonsSkipped: c.stats.hashComparisonsSkipped.Load(),
+		QueueLength:            c.GetSizeObjectToQueue(),
+		QueueDelayed:           c.GetSizeDelayedObjectToQueue(),
+		ConcurrencyLimit:       c.getConcurrency(),
+	}
+
+	c.cache.rLockAll()
+	defer c.cache.rUnlockAll()
+
+	stats.CacheSize = c.cache.size()
+	for _, sh := range c.cache.shards {
+		for _, storage := range sh.storages.states() {
+			if storage.isExecution {
+				stats.Running++
+			}
+		}
+	}
+
+	return stats
+}
+
+// countEviction учитывает вытеснение объекта из кэша
+func (c *CacheStorageWithQueue[T]) countEviction(reason Reason) {
+	switch reason {
+	case ReasonTtl:
+		c.stats.evictedByTtl.Add(1)
+
+	case ReasonSize:
+		c.stats.evictedBySize.Add(1)
+	}
+}
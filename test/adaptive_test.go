@@ -0,0 +1,99 @@
+package cachingstoragewithqueue_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestAdaptiveConcurrency(t *testing.T) {
+	t.Run("Тест 1. Недопустимые параметры", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithAdaptiveConcurrency[*objectsmispformat.ListFormatsMISP](1, 10, 100))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithAdaptiveConcurrency[*objectsmispformat.ListFormatsMISP](10, 5, 100))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithAdaptiveConcurrency[*objectsmispformat.ListFormatsMISP](2, 10, 0))
+		assert.Error(t, err)
+
+		//размер кэша должен как минимум в два раза превышать минимальное количество потоков
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithAdaptiveConcurrency[*objectsmispformat.ListFormatsMISP](6, 10, 100))
+		assert.Error(t, err)
+	})
+
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](12),
+		cachingstoragewithqueue.WithAdaptiveConcurrency[*objectsmispformat.ListFormatsMISP](2, 8, 100))
+	assert.NoError(t, err)
+
+	for i := range 6 {
+		id := fmt.Sprintf("%d", i)
+		assert.NoError(t, cache.AddObjectToCache(id, newTestObject(id, "", true)))
+	}
+
+	t.Run("Тест 2. Начальное количество потоков", func(t *testing.T) {
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 2)
+	})
+
+	t.Run("Тест 3. Увеличение количества потоков", func(t *testing.T) {
+		//количество не увеличивается, пока выполняется меньше половины допустимого количества функций
+		cache.AdaptConcurrency_Test(true, time.Millisecond)
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 2)
+
+		for i := range 6 {
+			cache.ChangeExecution(fmt.Sprintf("%d", i))
+		}
+
+		for range 10 {
+			cache.AdaptConcurrency_Test(true, time.Millisecond)
+		}
+
+		//количество ограничено половиной размера кэша
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 6)
+	})
+
+	t.Run("Тест 4. Уменьшение количества потоков", func(t *testing.T) {
+		cache.AdaptConcurrency_Test(false, time.Millisecond)
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 5)
+
+		cache.AdaptConcurrency_Test(true, time.Second)
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 4)
+
+		for range 10 {
+			cache.AdaptConcurrency_Test(false, time.Millisecond)
+		}
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 2)
+	})
+
+	t.Run("Тест 5. Изменение максимального количества потоков", func(t *testing.T) {
+		assert.Error(t, cache.SetConcurrency(1))
+
+		for range 10 {
+			cache.AdaptConcurrency_Test(true, time.Millisecond)
+		}
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 6)
+
+		//новое значение становится максимальным количеством и не перезаписывается
+		assert.NoError(t, cache.SetConcurrency(3))
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 3)
+
+		for range 10 {
+			cache.AdaptConcurrency_Test(true, time.Millisecond)
+		}
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 3)
+
+		cache.AdaptConcurrency_Test(false, time.Millisecond)
+		assert.Equal(t, cache.Stats().ConcurrencyLimit, 2)
+	})
+}
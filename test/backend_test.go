@@ -0,0 +1,300 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// TestBackendConformance общий набор тестов, который должно проходить каждое хранилище
+// объектов кэша
+func TestBackendConformance(t *testing.T) {
+	listBackends := []struct {
+		name string
+		//хранение объектов кэша в файлах
+		isFile bool
+	}{
+		{name: "память"},
+		{name: "файл", isFile: true},
+	}
+
+	for _, backend := range listBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			testBackendConformance(t, backend.isFile)
+		})
+	}
+}
+
+func testBackendConformance(t *testing.T, isFile bool) {
+	newCache := func(t *testing.T, shards int) *cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP] {
+		//для хранения объектов в памяти дополнительная опция не нужна
+		backendOption := cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](3600)
+		if isFile {
+			backendOption = cachingstoragewithqueue.WithFileBackend(t.TempDir(), cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{})
+		}
+
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](100),
+			cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](shards),
+			cachingstoragewithqueue.WithObjectHistory[*objectsmispformat.ListFormatsMISP](3, nil),
+			backendOption)
+		assert.NoError(t, err)
+		t.Cleanup(func() { assert.NoError(t, cache.Close()) })
+
+		return cache
+	}
+
+	t.Run("Тест 1. Добавление, чтение объекта и его функции", func(t *testing.T) {
+		cache := newCache(t, 1)
+
+		var count int
+		soc := newTestObject("1111", "info", true)
+		soc.SetFunc(func(int) bool {
+			count++
+
+			return true
+		})
+		assert.NoError(t, cache.AddObjectToCache("1111", soc))
+
+		obj, ok := cache.GetObjectFromCacheByKey("1111")
+		assert.True(t, ok)
+		assert.Equal(t, obj.GetEvent().Info, "info")
+
+		f, ok := cache.GetFuncFromCacheByKey("1111")
+		assert.True(t, ok)
+		assert.True(t, f(0))
+		assert.Equal(t, count, 1)
+
+		_, ok = cache.GetObjectFromCacheByKey("2222")
+		assert.False(t, ok)
+		assert.Equal(t, cache.GetCacheSize(), 1)
+	})
+
+	t.Run("Тест 2. Одинаковый объект отклоняется, отличающийся заменяет объект в кэше", func(t *testing.T) {
+		cache := newCache(t, 1)
+
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "first", true)))
+		assert.Error(t, cache.AddObjectToCache("1111", newTestObject("1111", "first", true)))
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "second", false)))
+
+		f, ok := cache.GetFuncFromCacheByKey("1111")
+		assert.True(t, ok)
+		assert.False(t, f(0))
+
+		history, ok := cache.GetObjectHistory("1111")
+		assert.True(t, ok)
+		assert.Len(t, history, 1)
+		assert.Equal(t, history[0].Object.GetEvent().Info, "first")
+	})
+
+	t.Run("Тест 3. Статус выполнения, количество попыток и последняя ошибка", func(t *testing.T) {
+		cache := newCache(t, 1)
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", false)))
+
+		cache.ChangeExecution("1111")
+		status, ok := cache.GetIsExecution("1111")
+		assert.True(t, ok)
+		assert.True(t, status)
+		assert.Equal(t, cache.GetIndexesWithIsExecutionStatus(), []string{"1111"})
+
+		cache.ChangeValues("1111", false)
+		entry, ok := cache.GetEntry("1111")
+		assert.True(t, ok)
+		assert.False(t, entry.IsExecution)
+		assert.False(t, entry.IsCompletedSuccessfully)
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+		assert.Error(t, entry.LastError)
+
+		cache.ChangeExecution("1111")
+		cache.ChangeValues("1111", true)
+		num, _ := cache.GetNumberExecutionAttempts("1111")
+		assert.Equal(t, num, 2)
+		assert.Equal(t, cache.GetIndexesWithIsCompletedSuccessfully(), []string{"1111"})
+	})
+
+	t.Run("Тест 4. Порядок по времени истечения жизни и удаление устаревших объектов", func(t *testing.T) {
+		cache := newCache(t, 4)
+
+		now := time.Now()
+		for i, id := range []string{"3333", "1111", "4444", "2222"} {
+			timeExpiry := now.Add(time.Duration(i+1) * time.Minute)
+			if id == "4444" {
+				timeExpiry = now.Add(-time.Minute)
+			}
+
+			assert.NoError(t, cache.AddObjectToCache_Test(id, timeExpiry, newTestObject(id, "info", true)))
+		}
+
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "4444")
+
+		index, f := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "4444")
+		assert.NotNil(t, f)
+
+		cache.DeleteForTimeExpiryObjectFromCache()
+		assert.Equal(t, cache.GetCacheSize(), 3)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "3333")
+
+		var keys []string
+		for key := range cache.All() {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, keys, []string{"3333", "1111", "2222"})
+	})
+
+	t.Run("Тест 5. Поиск и массовое удаление", func(t *testing.T) {
+		cache := newCache(t, 4)
+		for _, id := range []string{"1111", "2222", "3333", "4444"} {
+			assert.NoError(t, cache.AddObjectToCache(id, newTestObject(id, "info-"+id, true)))
+		}
+
+		keys := cache.Find(func(entry cachingstoragewithqueue.EntryInfo[*objectsmispformat.ListFormatsMISP]) bool {
+			return entry.Object.GetEvent().Info == "info-2222"
+		})
+		assert.Equal(t, keys, []string{"2222"})
+
+		assert.Equal(t, cache.DeleteByKeys("1111", "5555"), []string{"1111"})
+		assert.Len(t, cache.DeleteWhere(func(entry cachingstoragewithqueue.EntryInfo[*objectsmispformat.ListFormatsMISP]) bool {
+			return entry.ID != "4444"
+		}), 2)
+		assert.Equal(t, cache.GetCacheSize(), 1)
+
+		cache.CleanCache()
+		assert.Equal(t, cache.GetCacheSize(), 0)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "")
+	})
+
+	t.Run("Тест 6. Многократная замена больших объектов", func(t *testing.T) {
+		cache := newCache(t, 1)
+
+		for i := range 20 {
+			info := strings.Repeat(string(rune('a'+i)), 200_000)
+			for _, id := range []string{"1111", "2222"} {
+				cache.SetIsCompletedSuccessfullyTrue(id)
+				assert.NoError(t, cache.AddObjectToCache(id, newTestObject(id, info, true)))
+			}
+		}
+
+		//MatchingAndReplacement вспомогательного типа из examples оставляет объект из кэша,
+		//а заменяемые версии сохраняются в истории
+		for _, id := range []string{"1111", "2222"} {
+			obj, ok := cache.GetObjectFromCacheByKey(id)
+			assert.True(t, ok)
+			assert.True(t, obj.GetEvent().Info == strings.Repeat("a", 200_000))
+
+			history, ok := cache.GetObjectHistory(id)
+			assert.True(t, ok)
+			assert.Len(t, history, 3)
+		}
+	})
+
+	t.Run("Тест 7. Выполнение объекта синхронным обработчиком", func(t *testing.T) {
+		cache := newCache(t, 1)
+		cache.PushObjectToQueue(newTestObject("1111", "info", true))
+
+		cache.SyncExecution_Test(context.Background(), nil)
+
+		entry, ok := cache.GetEntry("1111")
+		assert.True(t, ok)
+		assert.False(t, entry.IsExecution)
+		assert.True(t, entry.IsCompletedSuccessfully)
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+	})
+
+	t.Run("Тест 8. Выполнение объектов асинхронным обработчиком", func(t *testing.T) {
+		cache := newCache(t, 4)
+		assert.NoError(t, cache.SetConcurrency(3))
+		for _, id := range []string{"1111", "2222", "3333"} {
+			cache.PushObjectToQueue(newTestObject(id, "info", true))
+		}
+
+		cache.AsyncExecution_Test(context.Background(), nil)
+
+		assert.Eventually(t, func() bool {
+			return len(cache.GetIndexesWithIsCompletedSuccessfully()) == 3
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Len(t, cache.GetIndexesWithIsExecutionStatus(), 0)
+
+		entry, ok := cache.GetEntry("2222")
+		assert.True(t, ok)
+		assert.False(t, entry.IsExecution)
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+	})
+
+	t.Run("Тест 9. Выполнение объектов групповым обработчиком", func(t *testing.T) {
+		var attempts atomic.Int32
+		handler := func(ctx context.Context, items []cachingstoragewithqueue.Item[*objectsmispformat.ListFormatsMISP]) []error {
+			errs := make([]error, len(items))
+			for k, item := range items {
+				//объект выполняется успешно только со второй попытки
+				if item.ID == "3333" && attempts.Add(1) == 1 {
+					errs[k] = errors.New("the remote server is unavailable")
+				}
+			}
+
+			return errs
+		}
+
+		//групповой обработчик задаётся при создании кэша, поэтому кэш создаётся отдельно
+		backendOption := cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](3600)
+		if isFile {
+			backendOption = cachingstoragewithqueue.WithFileBackend(t.TempDir(), cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{})
+		}
+
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](100),
+			cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](4),
+			cachingstoragewithqueue.WithTimeTick[*objectsmispformat.ListFormatsMISP](1),
+			cachingstoragewithqueue.WithBatchHandler(handler, 2, 0),
+			backendOption)
+		assert.NoError(t, err)
+		defer cache.Close()
+
+		for _, id := range []string{"1111", "2222", "3333"} {
+			cache.PushObjectToQueue(newTestObject(id, "info", false))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.StartAutomaticExecution(ctx)
+
+		assert.Eventually(t, func() bool {
+			return len(cache.GetIndexesWithIsCompletedSuccessfully()) == 3
+		}, 10*time.Second, 100*time.Millisecond)
+
+		entry, ok := cache.GetEntry("3333")
+		assert.True(t, ok)
+		assert.False(t, entry.IsExecution)
+		assert.Equal(t, entry.NumberExecutionAttempts, 2)
+	})
+}
+
+func TestFileBackendMaxSize(t *testing.T) {
+	_, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](5000))
+	assert.Error(t, err)
+
+	//опция WithFileBackend может быть задана после WithMaxSize
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](5000),
+		cachingstoragewithqueue.WithFileBackend(t.TempDir(), cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}))
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	assert.Equal(t, cache.Config().MaxSize, 5000)
+	assert.NoError(t, cache.SetMaxSize(100000))
+	assert.Error(t, cache.SetMaxSize(100000000))
+
+	memoryCache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+	assert.Error(t, memoryCache.SetMaxSize(5000))
+}
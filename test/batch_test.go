@@ -0,0 +1,143 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestBatchHandler(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		batches [][]string
+	)
+
+	handler := func(ctx context.Context, items []cachingstoragewithqueue.Item[*objectsmispformat.ListFormatsMISP]) []error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		ids := make([]string, 0, len(items))
+		errs := make([]error, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ID)
+
+			//объект выполняется успешно только со второй попытки
+			if item.ID == "3333" && item.Attempt == 1 {
+				errs = append(errs, errors.New("the remote server is unavailable"))
+			} else {
+				errs = append(errs, nil)
+			}
+		}
+		batches = append(batches, ids)
+
+		return errs
+	}
+
+	t.Run("Тест 1. Недопустимые параметры", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithBatchHandler[*objectsmispformat.ListFormatsMISP](nil, 10, 0))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithBatchHandler(handler, 0, 0))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithBatchHandler(handler, 10, -1))
+		assert.Error(t, err)
+	})
+
+	t.Run("Тест 2. Групповое выполнение объектов", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithTimeTick[*objectsmispformat.ListFormatsMISP](1),
+			cachingstoragewithqueue.WithBatchHandler(handler, 3, 0))
+		assert.NoError(t, err)
+
+		for _, id := range []string{"1111", "2222", "3333", "4444", "5555"} {
+			cache.PushObjectToQueue(newTestObject(id, "", false))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.StartAutomaticExecution(ctx)
+
+		assert.Eventually(t, func() bool {
+			return len(cache.GetIndexesWithIsCompletedSuccessfully()) == 5
+		}, 10*time.Second, 100*time.Millisecond)
+
+		mutex.Lock()
+		assert.Equal(t, batches[0], []string{"1111", "2222", "3333"})
+		for _, batch := range batches {
+			assert.LessOrEqual(t, len(batch), 3)
+		}
+		mutex.Unlock()
+
+		entry, ok := cache.GetEntry("3333")
+		assert.True(t, ok)
+		assert.Equal(t, entry.NumberExecutionAttempts, 2)
+
+		stats := cache.Stats()
+		assert.Equal(t, stats.Executed, uint64(6))
+		assert.Equal(t, stats.Failed, uint64(1))
+	})
+
+	t.Run("Тест 3. Ожидание заполнения группы", func(t *testing.T) {
+		mutex.Lock()
+		batches = nil
+		mutex.Unlock()
+
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithTimeTick[*objectsmispformat.ListFormatsMISP](1),
+			cachingstoragewithqueue.WithBatchHandler(handler, 3, 3600))
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.StartAutomaticExecution(ctx)
+
+		cache.PushObjectToQueue(newTestObject("6666", "", false))
+		cache.PushObjectToQueue(newTestObject("7777", "", false))
+		time.Sleep(2500 * time.Millisecond)
+
+		//неполная группа не выполняется до истечения времени ожидания
+		mutex.Lock()
+		assert.Len(t, batches, 0)
+		mutex.Unlock()
+
+		cache.PushObjectToQueue(newTestObject("8888", "", false))
+		assert.Eventually(t, func() bool {
+			return len(cache.GetIndexesWithIsCompletedSuccessfully()) == 3
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+
+	t.Run("Тест 4. Группа составляется из объектов с наименьшим временем истечения жизни", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](100),
+			cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](4),
+			cachingstoragewithqueue.WithBatchHandler(handler, 5, 0))
+		assert.NoError(t, err)
+
+		//объекты добавляются не по порядку времени истечения жизни
+		now := time.Now()
+		for i := range 40 {
+			id := fmt.Sprintf("%04d", i*7%40)
+			assert.NoError(t, cache.AddObjectToCache_Test(id, now.Add(time.Duration(i*7%40)*time.Second), newTestObject(id, "", false)))
+		}
+
+		assert.Equal(t, cache.ReadyBatch_Test(5), []string{"0000", "0001", "0002", "0003", "0004"})
+		keys := cache.ReadyBatch_Test(100)
+		assert.Len(t, keys, 40)
+		assert.True(t, slices.IsSorted(keys))
+	})
+}
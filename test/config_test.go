@@ -0,0 +1,93 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestRuntimeConfig(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+		cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](600),
+		cachingstoragewithqueue.WithTimeTick[*objectsmispformat.ListFormatsMISP](120))
+	assert.NoError(t, err)
+
+	t.Run("Тест 1. Текущие параметры хранилища", func(t *testing.T) {
+		assert.Equal(t, cache.Config(), cachingstoragewithqueue.Config{
+			MaxTtl:      600 * time.Second,
+			TimeTick:    120 * time.Second,
+			MaxSize:     10,
+			Concurrency: 0,
+		})
+	})
+
+	t.Run("Тест 2. Недопустимые значения параметров", func(t *testing.T) {
+		assert.Error(t, cache.SetMaxSize(2))
+		assert.Error(t, cache.SetMaxTtl(10))
+		assert.Error(t, cache.SetTimeTick(0))
+		//размер кэша должен минимум в два раза превышать количество потоков
+		assert.Error(t, cache.SetConcurrency(6))
+
+		assert.Equal(t, cache.Config().MaxSize, 10)
+		assert.Equal(t, cache.Config().Concurrency, 0)
+	})
+
+	t.Run("Тест 3. Изменение параметров", func(t *testing.T) {
+		assert.NoError(t, cache.SetConcurrency(5))
+		assert.Error(t, cache.SetMaxSize(9))
+		assert.NoError(t, cache.SetMaxSize(20))
+		assert.NoError(t, cache.SetConcurrency(10))
+		assert.NoError(t, cache.SetMaxTtl(3600))
+		assert.NoError(t, cache.SetConcurrency(1))
+
+		assert.Equal(t, cache.Config(), cachingstoragewithqueue.Config{
+			MaxTtl:      3600 * time.Second,
+			TimeTick:    120 * time.Second,
+			MaxSize:     20,
+			Concurrency: 1,
+		})
+	})
+
+	t.Run("Тест 4. Изменение интервала работающей автоматической обработки", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+
+		soc := newTestObject("1111", "", false)
+		soc.SetFunc(func(int) bool {
+			wg.Done()
+
+			return true
+		})
+		cache.PushObjectToQueue(soc)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.StartAutomaticExecution(ctx)
+
+		//при интервале 120 секунд объект не был бы выполнен до завершения теста
+		assert.NoError(t, cache.SetTimeTick(1))
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the new clock cycle time was not applied")
+		}
+
+		entry, ok := cache.GetEntry("1111")
+		assert.True(t, ok)
+		assert.True(t, entry.TimeExpiry.After(time.Now().Add(30*time.Minute)))
+	})
+}
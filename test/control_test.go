@@ -0,0 +1,208 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// contextObjectForCache вспомогательный тип реализующий интерфейс ContextHandler
+type contextObjectForCache struct {
+	*examples.SpecialObjectForCache[*objectsmispformat.ListFormatsMISP]
+	contextFunc func(ctx context.Context) bool
+}
+
+func (o *contextObjectForCache) GetContextFunc() func(ctx context.Context) bool {
+	return o.contextFunc
+}
+
+func TestRetryRequeueCancel(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	t.Run("Тест 1. Операции с отсутствующим объектом", func(t *testing.T) {
+		var entryErr *cachingstoragewithqueue.EntryError
+
+		err := cache.RetryNow("0000")
+		assert.ErrorIs(t, err, cachingstoragewithqueue.ErrNotFound)
+		assert.True(t, errors.As(err, &entryErr))
+		assert.Equal(t, entryErr.Key, "0000")
+
+		assert.ErrorIs(t, cache.Requeue("0000"), cachingstoragewithqueue.ErrNotFound)
+		assert.ErrorIs(t, cache.Cancel("0000"), cachingstoragewithqueue.ErrNotFound)
+
+		//объект находящийся в очереди нельзя выполнить повторно или вернуть в очередь
+		cache.PushObjectToQueue(newTestObject("0000", "", false))
+		assert.ErrorIs(t, cache.RetryNow("0000"), cachingstoragewithqueue.ErrWrongState)
+		assert.ErrorIs(t, cache.Requeue("0000"), cachingstoragewithqueue.ErrWrongState)
+
+		assert.NoError(t, cache.Cancel("0000"))
+		assert.Equal(t, cache.GetSizeObjectToQueue(), 0)
+	})
+
+	t.Run("Тест 2. Повторное выполнение объекта", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "", false)))
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "", false)))
+
+		cache.ChangeExecution("2222")
+		assert.ErrorIs(t, cache.RetryNow("2222"), cachingstoragewithqueue.ErrWrongState)
+		cache.ChangeValues("2222", false)
+		cache.ChangeExecution("2222")
+		cache.ChangeValues("2222", false)
+
+		assert.NoError(t, cache.RetryNow("2222"))
+
+		entry, ok := cache.GetEntry("2222")
+		assert.True(t, ok)
+		assert.Equal(t, entry.NumberExecutionAttempts, 0)
+		assert.NoError(t, entry.LastError)
+
+		//объект выполняется раньше объекта, который был добавлен в кэш раньше него
+		key, _ := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, key, "2222")
+	})
+
+	t.Run("Тест 3. Возврат объекта в очередь", func(t *testing.T) {
+		assert.NoError(t, cache.Requeue("1111"))
+		_, ok := cache.GetObjectFromCacheByKey("1111")
+		assert.False(t, ok)
+
+		obj, isEmpty := cache.PullObjectFromQueue()
+		assert.False(t, isEmpty)
+		assert.Equal(t, obj.GetID(), "1111")
+		assert.NoError(t, cache.AddObjectToCache(obj.GetID(), obj))
+	})
+
+	t.Run("Тест 4. Отмена выполняющегося объекта", func(t *testing.T) {
+		started := make(chan struct{})
+		obj := &contextObjectForCache{
+			SpecialObjectForCache: newTestObject("3333", "", false),
+			contextFunc: func(ctx context.Context) bool {
+				close(started)
+				<-ctx.Done()
+
+				return false
+			},
+		}
+		assert.NoError(t, cache.AddObjectToCache("3333", obj))
+
+		cache.ChangeExecution("3333")
+		f, ok := cache.GetFuncFromCacheByKey("3333")
+		assert.True(t, ok)
+
+		done := make(chan bool)
+		go func() {
+			done <- f(0)
+		}()
+		<-started
+
+		assert.ErrorIs(t, cache.Requeue("3333"), cachingstoragewithqueue.ErrWrongState)
+		assert.NoError(t, cache.Cancel("3333"))
+
+		select {
+		case status := <-done:
+			assert.False(t, status)
+
+		case <-time.After(time.Second):
+			t.Fatal("the execution context was not canceled")
+		}
+
+		cache.ChangeValues("3333", false)
+		_, ok = cache.GetObjectFromCacheByKey("3333")
+		assert.False(t, ok)
+	})
+
+	t.Run("Тест 5. Повторное выполнение объекта в асинхронном режиме", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](2))
+		assert.NoError(t, err)
+
+		executed := make(chan struct{}, 2)
+		obj := newTestObject("4444", "", false)
+		obj.SetFunc(func(int) bool {
+			executed <- struct{}{}
+
+			return false
+		})
+		cache.PushObjectToQueue(obj)
+
+		//ожидание выполнения функции объекта и изменения его состояния
+		wait := func() {
+			select {
+			case <-executed:
+			case <-time.After(time.Second):
+				t.Fatal("the function of the object was not executed")
+			}
+
+			assert.Eventually(t, func() bool {
+				status, _ := cache.GetIsExecution("4444")
+
+				return !status
+			}, time.Second, 10*time.Millisecond)
+		}
+
+		cache.AsyncExecution_Test(context.Background(), nil)
+		wait()
+
+		//без RetryNow неуспешно выполненный объект повторно не выполняется
+		cache.AsyncExecution_Test(context.Background(), nil)
+		assert.Empty(t, executed)
+
+		assert.NoError(t, cache.RetryNow("4444"))
+		cache.AsyncExecution_Test(context.Background(), nil)
+		wait()
+
+		num, _ := cache.GetNumberExecutionAttempts("4444")
+		assert.Equal(t, num, 1)
+	})
+
+	t.Run("Тест 6. Завершение отмененного выполнения не меняет новый объект", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](2))
+		assert.NoError(t, err)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		finished := make(chan struct{})
+		obj := &resultObjectForCache{
+			SpecialObjectForCache: newTestObject("5555", "", false),
+			resultFunc: func(context.Context) (any, bool) {
+				defer close(finished)
+
+				//функция не учитывает отмену контекста и завершается позже
+				close(started)
+				<-release
+
+				return "stale result", true
+			},
+		}
+		cache.PushObjectToQueue(obj)
+
+		cache.AsyncExecution_Test(context.Background(), nil)
+		<-started
+
+		assert.NoError(t, cache.Cancel("5555"))
+		assert.NoError(t, cache.AddObjectToCache("5555", newTestObject("5555", "", false)))
+
+		close(release)
+		<-finished
+		time.Sleep(50 * time.Millisecond)
+
+		entry, ok := cache.GetEntry("5555")
+		assert.True(t, ok)
+		assert.Equal(t, entry.NumberExecutionAttempts, 0)
+		assert.False(t, entry.IsExecution)
+		assert.False(t, entry.IsCompletedSuccessfully)
+		assert.Nil(t, entry.Result)
+	})
+}
@@ -0,0 +1,143 @@
+package cachingstoragewithqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestDeadLetterQueue(t *testing.T) {
+	_, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithDeadLetterQueue[*objectsmispformat.ListFormatsMISP](10, 10))
+	assert.Error(t, err)
+
+	_, err = cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithDeadLetterQueue[*objectsmispformat.ListFormatsMISP](60, 0))
+	assert.Error(t, err)
+
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+		cachingstoragewithqueue.WithDeadLetterQueue[*objectsmispformat.ListFormatsMISP](60, 2))
+	assert.NoError(t, err)
+
+	//неуспешное выполнение функции объекта за все попытки
+	exhaust := func(key string) {
+		for range 3 {
+			cache.ChangeExecution(key)
+			cache.ChangeValues(key, false)
+		}
+	}
+
+	t.Run("Тест 1. Перемещение объекта при превышении размера кэша", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", false)))
+		exhaust("1111")
+
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+		assert.Equal(t, cache.GetCacheSize(), 0)
+
+		dl, ok := cache.GetDeadLetter("1111")
+		assert.True(t, ok)
+		assert.Equal(t, dl.ID, "1111")
+		assert.Equal(t, dl.Object.Event.Info, "info")
+		assert.Equal(t, dl.NumberExecutionAttempts, 3)
+		assert.Equal(t, dl.Reason, cachingstoragewithqueue.ReasonSize)
+		assert.Error(t, dl.LastError)
+		assert.False(t, dl.TimeMain.IsZero())
+		assert.True(t, dl.TimeExpiry.After(dl.TimeDead))
+	})
+
+	t.Run("Тест 2. Перемещение объекта по истечении времени жизни", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache_Test("2222", time.Now().Add(-time.Minute), newTestObject("2222", "info", false)))
+		exhaust("2222")
+
+		//успешно выполненный объект в очередь недоставленных объектов не перемещается
+		assert.NoError(t, cache.AddObjectToCache_Test("3333", time.Now().Add(-time.Minute), newTestObject("3333", "info", false)))
+		cache.ChangeExecution("3333")
+		cache.ChangeValues("3333", true)
+
+		cache.DeleteForTimeExpiryObjectFromCache()
+
+		list := cache.GetDeadLetters()
+		assert.Len(t, list, 2)
+		assert.Equal(t, list[0].ID, "1111")
+		assert.Equal(t, list[1].ID, "2222")
+		assert.Equal(t, list[1].Reason, cachingstoragewithqueue.ReasonTtl)
+
+		_, ok := cache.GetDeadLetter("3333")
+		assert.False(t, ok)
+	})
+
+	t.Run("Тест 3. Ограничение размера очереди недоставленных объектов", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache_Test("4444", time.Now().Add(-time.Minute), newTestObject("4444", "info", false)))
+		exhaust("4444")
+		cache.DeleteForTimeExpiryObjectFromCache()
+
+		list := cache.GetDeadLetters()
+		assert.Len(t, list, 2)
+		assert.Equal(t, list[0].ID, "2222")
+		assert.Equal(t, list[1].ID, "4444")
+	})
+
+	t.Run("Тест 4. Повторное добавление объекта в очередь", func(t *testing.T) {
+		assert.Error(t, cache.RequeueDeadLetter("1111"))
+		assert.NoError(t, cache.RequeueDeadLetter("2222"))
+		assert.Len(t, cache.GetDeadLetters(), 1)
+
+		obj, isEmpty := cache.PullObjectFromQueue()
+		assert.False(t, isEmpty)
+		assert.Equal(t, obj.GetID(), "2222")
+		assert.Equal(t, obj.GetObject().Event.Info, "info")
+		assert.False(t, obj.GetFunc()(0))
+
+		assert.NoError(t, cache.AddObjectToCache(obj.GetID(), obj))
+		num, ok := cache.GetNumberExecutionAttempts("2222")
+		assert.True(t, ok)
+		assert.Equal(t, num, 0)
+	})
+
+	t.Run("Тест 5. Удаление объектов из очереди недоставленных объектов", func(t *testing.T) {
+		assert.Equal(t, cache.PurgeDeadLetters("9999"), 0)
+		assert.Equal(t, cache.PurgeDeadLetters(), 1)
+		assert.Len(t, cache.GetDeadLetters(), 0)
+	})
+
+	t.Run("Тест 6. Перемещение объекта после одной неуспешной попытки", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache_Test("5555", time.Now().Add(-time.Minute), newTestObject("5555", "info", false)))
+		cache.ChangeExecution("5555")
+		cache.ChangeValues("5555", false)
+
+		cache.DeleteForTimeExpiryObjectFromCache()
+
+		dl, ok := cache.GetDeadLetter("5555")
+		assert.True(t, ok)
+		assert.Equal(t, dl.NumberExecutionAttempts, 1)
+	})
+
+	t.Run("Тест 7. Выполняющийся объект не удаляется при превышении размера кэша", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithDeadLetterQueue[*objectsmispformat.ListFormatsMISP](60, 2))
+		assert.NoError(t, err)
+
+		assert.NoError(t, cache.AddObjectToCache("6666", newTestObject("6666", "info", false)))
+		for range 2 {
+			cache.ChangeExecution("6666")
+			cache.ChangeValues("6666", false)
+		}
+		//последняя попытка выполнения еще не завершена
+		cache.ChangeExecution("6666")
+
+		assert.Error(t, cache.DeleteOldestObjectFromCache())
+		assert.Equal(t, cache.GetCacheSize(), 1)
+		assert.Len(t, cache.GetDeadLetters(), 0)
+
+		cache.ChangeValues("6666", false)
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+		assert.Equal(t, cache.GetCacheSize(), 0)
+		assert.Len(t, cache.GetDeadLetters(), 1)
+	})
+}
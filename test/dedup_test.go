@@ -0,0 +1,132 @@
+package cachingstoragewithqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestDedupWindow(t *testing.T) {
+	t.Run("Тест 1. Проверка параметров окна дедупликации", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithDedupWindow[*objectsmispformat.ListFormatsMISP](10, 100))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithDedupWindow[*objectsmispformat.ListFormatsMISP](60, 0))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithDedupBloomFilter[*objectsmispformat.ListFormatsMISP](60, 1000, 0.7))
+		assert.Error(t, err)
+	})
+
+	listOptions := map[string]func() (*cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP], error){
+		"tombstones": func() (*cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP], error) {
+			return cachingstoragewithqueue.NewCacheStorage(
+				cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+				cachingstoragewithqueue.WithDedupWindow[*objectsmispformat.ListFormatsMISP](60, 100))
+		},
+		"bloom filter": func() (*cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP], error) {
+			return cachingstoragewithqueue.NewCacheStorage(
+				cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+				cachingstoragewithqueue.WithDedupBloomFilter[*objectsmispformat.ListFormatsMISP](60, 1000, 0.001))
+		},
+	}
+
+	for name, newCache := range listOptions {
+		t.Run("Тест 2. Повторное выполнение удаленных объектов, "+name, func(t *testing.T) {
+			cache, err := newCache()
+			assert.NoError(t, err)
+
+			expired := time.Now().Add(-time.Second)
+			assert.NoError(t, cache.AddObjectToCache_Test("dedup-1111", expired, newTestObject("dedup-1111", "", true)))
+			assert.NoError(t, cache.AddObjectToCache_Test("dedup-2222", expired, newTestObject("dedup-2222", "", true)))
+
+			//первый объект выполнен успешно, второй нет
+			cache.ChangeExecution("dedup-1111")
+			cache.ChangeValues("dedup-1111", true)
+			cache.ChangeExecution("dedup-2222")
+			cache.ChangeValues("dedup-2222", false)
+
+			cache.DeleteForTimeExpiryObjectFromCache()
+			assert.Equal(t, cache.GetCacheSize(), 0)
+
+			//успешно выполненный объект повторно не добавляется
+			assert.Error(t, cache.AddObjectToCache("dedup-1111", newTestObject("dedup-1111", "", true)))
+			//неуспешно выполненный объект добавляется
+			assert.NoError(t, cache.AddObjectToCache("dedup-2222", newTestObject("dedup-2222", "", true)))
+			//новый объект добавляется
+			assert.NoError(t, cache.AddObjectToCache("dedup-3333", newTestObject("dedup-3333", "", true)))
+		})
+	}
+
+	t.Run("Тест 3. При превышении размера окна удаляются самые старые ключи", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithDedupWindow[*objectsmispformat.ListFormatsMISP](60, 1))
+		assert.NoError(t, err)
+
+		for _, id := range []string{"dedup-1111", "dedup-2222"} {
+			assert.NoError(t, cache.AddObjectToCache_Test(id, time.Now().Add(-time.Second), newTestObject(id, "", true)))
+			cache.ChangeExecution(id)
+			cache.ChangeValues(id, true)
+			cache.DeleteForTimeExpiryObjectFromCache()
+		}
+
+		assert.NoError(t, cache.AddObjectToCache("dedup-1111", newTestObject("dedup-1111", "", true)))
+		assert.Error(t, cache.AddObjectToCache("dedup-2222", newTestObject("dedup-2222", "", true)))
+	})
+
+	t.Run("Тест 4. Ключи вероятностного окна удаляются не позднее двух ttl", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithDedupBloomFilter[*objectsmispformat.ListFormatsMISP](60, 1000, 0.001))
+		assert.NoError(t, err)
+
+		remember := func(id string) {
+			assert.NoError(t, cache.AddObjectToCache_Test(id, time.Now().Add(-time.Second), newTestObject(id, "", true)))
+			cache.ChangeExecution(id)
+			cache.ChangeValues(id, true)
+			cache.DeleteForTimeExpiryObjectFromCache()
+		}
+
+		//после одного ttl ключ остается в предыдущем поколении
+		remember("dedup-1111")
+		cache.ShiftDedupWindow_Test(61 * time.Second)
+		assert.Error(t, cache.AddObjectToCache("dedup-1111", newTestObject("dedup-1111", "", true)))
+
+		//после двух ttl без обращений к окну ключи обоих поколений устарели
+		remember("dedup-2222")
+		cache.ShiftDedupWindow_Test(121 * time.Second)
+		assert.NoError(t, cache.AddObjectToCache("dedup-2222", newTestObject("dedup-2222", "", true)))
+	})
+
+	t.Run("Тест 5. Ключи вероятностного окна удаляются раньше ttl при заполнении фильтра", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithDedupBloomFilter[*objectsmispformat.ListFormatsMISP](60, 2, 0.001))
+		assert.NoError(t, err)
+
+		remember := func(id string) {
+			assert.NoError(t, cache.AddObjectToCache_Test(id, time.Now().Add(-time.Second), newTestObject(id, "", true)))
+			cache.ChangeExecution(id)
+			cache.ChangeValues(id, true)
+			cache.DeleteForTimeExpiryObjectFromCache()
+		}
+
+		//заполненное текущее поколение становится предыдущим, ключ остается в окне
+		remember("dedup-1111")
+		remember("dedup-2222")
+		remember("dedup-3333")
+		assert.Error(t, cache.AddObjectToCache("dedup-1111", newTestObject("dedup-1111", "", true)))
+
+		//после повторного заполнения ключ удаляется из окна до истечения ttl
+		remember("dedup-4444")
+		assert.NoError(t, cache.AddObjectToCache("dedup-1111", newTestObject("dedup-1111", "", true)))
+	})
+}
@@ -0,0 +1,113 @@
+package cachingstoragewithqueue_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// delayedObjectForCache вспомогательный тип реализующий интерфейс DelayedHandler
+type delayedObjectForCache struct {
+	*examples.SpecialObjectForCache[*objectsmispformat.ListFormatsMISP]
+	notBefore time.Time
+}
+
+func (o *delayedObjectForCache) NotBefore() time.Time {
+	return o.notBefore
+}
+
+func TestDelayedExecution(t *testing.T) {
+	newCache := func() *cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP] {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](4),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory))
+		assert.NoError(t, err)
+
+		return cache
+	}
+
+	t.Run("Тест 1. Отложенные объекты не забираются из очереди", func(t *testing.T) {
+		cache := newCache()
+		cache.PushObjectToQueueAfter(newTestObject("1111", "", true), time.Hour)
+		cache.PushObjectToQueueAt(newTestObject("2222", "", true), time.Now().Add(time.Hour))
+		cache.PushObjectToQueue(&delayedObjectForCache{SpecialObjectForCache: newTestObject("3333", "", true), notBefore: time.Now().Add(time.Hour)})
+
+		assert.Equal(t, cache.GetSizeObjectToQueue(), 3)
+		assert.Equal(t, cache.GetSizeDelayedObjectToQueue(), 3)
+		assert.Equal(t, cache.Stats().QueueDelayed, 3)
+
+		_, isEmpty := cache.PullObjectFromQueue()
+		assert.True(t, isEmpty)
+
+		list, isEmpty := cache.PullMaxObjectFromQueue()
+		assert.True(t, isEmpty)
+		assert.Empty(t, list)
+	})
+
+	t.Run("Тест 2. Объект становится доступным после наступления времени", func(t *testing.T) {
+		cache := newCache()
+		cache.PushObjectToQueueAfter(newTestObject("1111", "", true), 300*time.Millisecond)
+		cache.PushObjectToQueueAfter(newTestObject("2222", "", true), 100*time.Millisecond)
+		//объект со временем в прошлом сразу доступен для обработки
+		cache.PushObjectToQueueAt(newTestObject("3333", "", true), time.Now().Add(-time.Minute))
+		assert.Equal(t, cache.GetSizeDelayedObjectToQueue(), 2)
+
+		obj, isEmpty := cache.PullObjectFromQueue()
+		assert.False(t, isEmpty)
+		assert.Equal(t, obj.GetID(), "3333")
+
+		_, isEmpty = cache.PullObjectFromQueue()
+		assert.True(t, isEmpty)
+
+		time.Sleep(400 * time.Millisecond)
+
+		//объекты забираются в порядке наступления времени выполнения
+		list, isEmpty := cache.PullMaxObjectFromQueue()
+		assert.False(t, isEmpty)
+		assert.Len(t, list, 2)
+		assert.Equal(t, list[0].GetID(), "2222")
+		assert.Equal(t, list[1].GetID(), "1111")
+		assert.Equal(t, cache.GetSizeObjectToQueue(), 0)
+	})
+
+	t.Run("Тест 3. Отмена и очистка отложенных объектов", func(t *testing.T) {
+		cache := newCache()
+		cache.PushObjectToQueueAfter(newTestObject("1111", "", true), time.Hour)
+		cache.PushObjectToQueueAfter(newTestObject("2222", "", true), time.Hour)
+
+		assert.ErrorIs(t, cache.RetryNow("1111"), cachingstoragewithqueue.ErrWrongState)
+		assert.NoError(t, cache.Cancel("1111"))
+		assert.Equal(t, cache.GetSizeDelayedObjectToQueue(), 1)
+
+		cache.CleanQueue()
+		assert.Equal(t, cache.GetSizeObjectToQueue(), 0)
+	})
+
+	t.Run("Тест 4. Сохранение отложенных объектов в снимке", func(t *testing.T) {
+		cache := newCache()
+		cache.PushObjectToQueueAfter(newTestObject("1111", "", true), time.Hour)
+		cache.PushObjectToQueue(newTestObject("2222", "", true))
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+
+		restored := newCache()
+		assert.NoError(t, restored.LoadSnapshot(buf))
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 2)
+		assert.Equal(t, restored.GetSizeDelayedObjectToQueue(), 1)
+
+		obj, isEmpty := restored.PullObjectFromQueue()
+		assert.False(t, isEmpty)
+		assert.Equal(t, obj.GetID(), "2222")
+
+		_, isEmpty = restored.PullObjectFromQueue()
+		assert.True(t, isEmpty)
+	})
+}
@@ -0,0 +1,62 @@
+package cachingstoragewithqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestEntries(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](300),
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+		cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](4))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	listId := []string{"2222-2222", "1111-1111", "3333-3333"}
+	for i, id := range listId {
+		assert.NoError(t, cache.AddObjectToCache_Test(id, now.Add(time.Duration(10-i)*time.Minute), newTestObject(id, "", false)))
+	}
+
+	t.Run("Тест 1. Получить состояние объекта по ключу", func(t *testing.T) {
+		_, ok := cache.GetEntry("0000-0000")
+		assert.False(t, ok)
+
+		cache.ChangeExecution("1111-1111")
+		entry, ok := cache.GetEntry("1111-1111")
+		assert.True(t, ok)
+		assert.Equal(t, entry.ID, "1111-1111")
+		assert.Equal(t, entry.Object.GetID(), "1111-1111")
+		assert.True(t, entry.IsExecution)
+		assert.False(t, entry.IsCompletedSuccessfully)
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+		assert.NoError(t, entry.LastError)
+		assert.True(t, entry.TimeExpiry.After(entry.TimeMain))
+
+		//неудачное выполнение функции сохраняется как последняя ошибка
+		cache.ChangeValues("1111-1111", false)
+		entry, _ = cache.GetEntry("1111-1111")
+		assert.False(t, entry.IsExecution)
+		assert.Error(t, entry.LastError)
+	})
+
+	t.Run("Тест 2. Итерация по снимку кэша", func(t *testing.T) {
+		var keys []string
+		for key, entry := range cache.All() {
+			assert.Equal(t, key, entry.ID)
+			keys = append(keys, key)
+
+			//изменение кэша во время итерации не влияет на снимок
+			cache.CleanCache()
+		}
+
+		//объекты перебираются в порядке возрастания времени истечения жизни
+		assert.Equal(t, keys, []string{"3333-3333", "1111-1111", "2222-2222"})
+		assert.Equal(t, cache.GetCacheSize(), 0)
+	})
+}
@@ -0,0 +1,133 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestSubscribe(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	//чтение событий из канала с ограничением времени ожидания
+	receive := func(t *testing.T, ch <-chan cachingstoragewithqueue.Event[*objectsmispformat.ListFormatsMISP]) cachingstoragewithqueue.Event[*objectsmispformat.ListFormatsMISP] {
+		select {
+		case e := <-ch:
+			return e
+
+		case <-time.After(time.Second):
+			t.Fatal("the event was not received")
+		}
+
+		return cachingstoragewithqueue.Event[*objectsmispformat.ListFormatsMISP]{}
+	}
+
+	t.Run("Тест 1. Недопустимые параметры подписки", func(t *testing.T) {
+		_, err := cache.Subscribe(context.Background(), nil, cachingstoragewithqueue.WithSubscriberBuffer(0))
+		assert.Error(t, err)
+
+		_, err = cache.Subscribe(context.Background(), nil, cachingstoragewithqueue.WithSubscriberPolicy(5))
+		assert.Error(t, err)
+	})
+
+	t.Run("Тест 2. Получение событий жизненного цикла объекта", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := cache.Subscribe(ctx, nil)
+		assert.NoError(t, err)
+
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", true)))
+		cache.ChangeExecution("1111")
+		cache.ChangeValues("1111", false)
+		cache.ChangeExecution("1111")
+		cache.ChangeValues("1111", true)
+
+		expected := []cachingstoragewithqueue.EventType{
+			cachingstoragewithqueue.EventAdmitted,
+			cachingstoragewithqueue.EventStarted,
+			cachingstoragewithqueue.EventFailed,
+			cachingstoragewithqueue.EventStarted,
+			cachingstoragewithqueue.EventSucceeded,
+		}
+		for _, eventType := range expected {
+			e := receive(t, ch)
+			assert.Equal(t, e.Type, eventType)
+			assert.Equal(t, e.ID, "1111")
+			assert.Equal(t, e.Object.Event.Info, "info")
+			assert.False(t, e.Time.IsZero())
+		}
+	})
+
+	t.Run("Тест 3. Фильтр событий и вытеснение объектов", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := cache.Subscribe(ctx, cachingstoragewithqueue.FilterByType[*objectsmispformat.ListFormatsMISP](
+			cachingstoragewithqueue.EventEvicted,
+			cachingstoragewithqueue.EventExpired,
+		))
+		assert.NoError(t, err)
+
+		assert.NoError(t, cache.AddObjectToCache_Test("2222", time.Now().Add(-time.Minute), newTestObject("2222", "info", true)))
+		cache.DeleteForTimeExpiryObjectFromCache()
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+
+		e := receive(t, ch)
+		assert.Equal(t, e.Type, cachingstoragewithqueue.EventExpired)
+		assert.Equal(t, e.Reason, cachingstoragewithqueue.ReasonTtl)
+		assert.Equal(t, e.ID, "2222")
+
+		e = receive(t, ch)
+		assert.Equal(t, e.Type, cachingstoragewithqueue.EventEvicted)
+		assert.Equal(t, e.Reason, cachingstoragewithqueue.ReasonSize)
+		assert.Equal(t, e.ID, "1111")
+	})
+
+	t.Run("Тест 4. Переполнение буфера и завершение подписки", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		ch, err := cache.Subscribe(ctx, nil, cachingstoragewithqueue.WithSubscriberBuffer(1))
+		assert.NoError(t, err)
+
+		dropped := cache.Stats().EventsDropped
+		assert.NoError(t, cache.AddObjectToCache("3333", newTestObject("3333", "info", true)))
+		assert.NoError(t, cache.AddObjectToCache("4444", newTestObject("4444", "info", true)))
+		assert.Equal(t, cache.Stats().EventsDropped, dropped+1)
+
+		assert.Equal(t, receive(t, ch).ID, "3333")
+
+		cancel()
+		for range ch {
+		}
+	})
+
+	t.Run("Тест 5. Ожидание освобождения буфера подписчика", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch, err := cache.Subscribe(ctx, nil,
+			cachingstoragewithqueue.WithSubscriberBuffer(1),
+			cachingstoragewithqueue.WithSubscriberPolicy(cachingstoragewithqueue.DeliveryBlock))
+		assert.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			assert.NoError(t, cache.AddObjectToCache("5555", newTestObject("5555", "info", true)))
+			assert.NoError(t, cache.AddObjectToCache("6666", newTestObject("6666", "info", true)))
+		}()
+
+		assert.Equal(t, receive(t, ch).ID, "5555")
+		assert.Equal(t, receive(t, ch).ID, "6666")
+		<-done
+	})
+}
@@ -0,0 +1,88 @@
+package cachingstoragewithqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestExpiryIndex(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage[*objectsmispformat.ListFormatsMISP](
+		cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](300),
+		cachingstoragewithqueue.WithTimeTick[*objectsmispformat.ListFormatsMISP](3),
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	//ключи и время истечения жизни объектов, добавляются не по порядку
+	listObjects := []struct {
+		id         string
+		timeExpiry time.Time
+	}{
+		{"3333-3333", now.Add(30 * time.Second)},
+		{"1111-1111", now.Add(10 * time.Second)},
+		{"4444-4444", now.Add(-5 * time.Second)},
+		{"2222-2222", now.Add(20 * time.Second)},
+		{"5555-5555", now.Add(-10 * time.Second)},
+	}
+
+	for _, v := range listObjects {
+		assert.NoError(t, cache.AddObjectToCache_Test(v.id, v.timeExpiry, newTestObject(v.id, "", true)))
+	}
+
+	t.Run("Тест 1. Самый старый объект определяется по наименьшему timeExpiry", func(t *testing.T) {
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "5555-5555")
+	})
+
+	t.Run("Тест 2. Поиск функции пропускает выполняющиеся и успешно выполненные объекты", func(t *testing.T) {
+		cache.SetIsExecutionTrue("5555-5555")
+		cache.SetIsCompletedSuccessfullyTrue("4444-4444")
+
+		index, f := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "1111-1111")
+		assert.NotNil(t, f)
+
+		index, obj := cache.GetObjectFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "1111-1111")
+		assert.Equal(t, obj.GetID(), "1111-1111")
+
+		//после завершения выполнения объект снова доступен для поиска
+		cache.ChangeValues("5555-5555", false)
+		index, _ = cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "5555-5555")
+	})
+
+	t.Run("Тест 3. Обновление timeExpiry меняет порядок объектов", func(t *testing.T) {
+		cache.SetTimeExpiry("1111-1111")
+
+		cache.SetIsExecutionTrue("5555-5555")
+		index, _ := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "2222-2222")
+		cache.SetIsExecutionFalse("5555-5555")
+	})
+
+	t.Run("Тест 4. Удаляются только объекты у которых истекло время жизни", func(t *testing.T) {
+		cache.DeleteForTimeExpiryObjectFromCache()
+
+		assert.Equal(t, cache.GetCacheSize(), 3)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "2222-2222")
+
+		_, ok := cache.GetObjectFromCacheByKey("4444-4444")
+		assert.False(t, ok)
+		_, ok = cache.GetObjectFromCacheByKey("5555-5555")
+		assert.False(t, ok)
+	})
+
+	t.Run("Тест 5. Очистка кэша очищает и индексы", func(t *testing.T) {
+		cache.CleanCache()
+
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "")
+		index, f := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "")
+		assert.Nil(t, f)
+	})
+}
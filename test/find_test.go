@@ -0,0 +1,113 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestFindAndDelete(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](300),
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](20),
+		cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](4))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	for i := range 10 {
+		id := fmt.Sprintf("find-%d", i)
+		assert.NoError(t, cache.AddObjectToCache_Test(id, now.Add(time.Duration(i+1)*time.Minute), newTestObject(id, "", false)))
+	}
+
+	//объекты с чётными номерами дважды выполнены неуспешно
+	for i := 0; i < 10; i += 2 {
+		id := fmt.Sprintf("find-%d", i)
+		for range 2 {
+			cache.ChangeExecution(id)
+			cache.ChangeValues(id, false)
+		}
+	}
+
+	t.Run("Тест 1. Поиск объектов по условию", func(t *testing.T) {
+		keys := cache.Find(func(e cachingstoragewithqueue.EntryInfo[*objectsmispformat.ListFormatsMISP]) bool {
+			return e.NumberExecutionAttempts >= 2 && !e.IsCompletedSuccessfully
+		})
+		assert.Equal(t, keys, []string{"find-0", "find-2", "find-4", "find-6", "find-8"})
+		assert.Equal(t, cache.GetCacheSize(), 10)
+	})
+
+	t.Run("Тест 2. Удаление объектов по условию", func(t *testing.T) {
+		keys := cache.DeleteWhere(func(e cachingstoragewithqueue.EntryInfo[*objectsmispformat.ListFormatsMISP]) bool {
+			return e.NumberExecutionAttempts >= 2
+		})
+		assert.Equal(t, len(keys), 5)
+		assert.Equal(t, cache.GetCacheSize(), 5)
+
+		keys = cache.DeleteWhere(func(e cachingstoragewithqueue.EntryInfo[*objectsmispformat.ListFormatsMISP]) bool {
+			return false
+		})
+		assert.Empty(t, keys)
+	})
+
+	t.Run("Тест 3. Удаление объектов по списку ключей", func(t *testing.T) {
+		keys := cache.DeleteByKeys("find-1", "find-2", "find-3")
+		assert.Equal(t, keys, []string{"find-1", "find-3"})
+		assert.Equal(t, cache.GetCacheSize(), 3)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "find-5")
+	})
+
+	t.Run("Тест 4. Удаление выполняющихся объектов отменяет контекст их выполнения", func(t *testing.T) {
+		//запуск функции объекта, которая завершается только при отмене контекста
+		run := func(id string) <-chan bool {
+			started := make(chan struct{})
+			obj := &contextObjectForCache{
+				SpecialObjectForCache: newTestObject(id, "", false),
+				contextFunc: func(ctx context.Context) bool {
+					close(started)
+					<-ctx.Done()
+
+					return false
+				},
+			}
+			assert.NoError(t, cache.AddObjectToCache(id, obj))
+
+			cache.ChangeExecution(id)
+			f, ok := cache.GetFuncFromCacheByKey(id)
+			assert.True(t, ok)
+
+			done := make(chan bool)
+			go func() {
+				done <- f(0)
+			}()
+			<-started
+
+			return done
+		}
+
+		waitCanceled := func(done <-chan bool) {
+			select {
+			case status := <-done:
+				assert.False(t, status)
+
+			case <-time.After(time.Second):
+				t.Fatal("the execution context was not canceled")
+			}
+		}
+
+		done := run("running-1")
+		assert.Equal(t, cache.DeleteWhere(func(e cachingstoragewithqueue.EntryInfo[*objectsmispformat.ListFormatsMISP]) bool {
+			return e.IsExecution
+		}), []string{"running-1"})
+		waitCanceled(done)
+
+		done = run("running-2")
+		assert.Equal(t, cache.DeleteByKeys("running-2"), []string{"running-2"})
+		waitCanceled(done)
+	})
+}
@@ -0,0 +1,105 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](300),
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	var countLoad atomic.Int32
+	loader := func(ctx context.Context) (*objectsmispformat.ListFormatsMISP, error) {
+		countLoad.Add(1)
+		time.Sleep(100 * time.Millisecond)
+
+		obj := objectsmispformat.NewListFormatsMISP()
+		obj.ID = "load-1111"
+
+		return obj, nil
+	}
+
+	t.Run("Тест 1. Одновременные вызовы разделяют одну загрузку", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				obj, err := cache.GetOrLoad(context.Background(), "load-1111", loader)
+				assert.NoError(t, err)
+				assert.Equal(t, obj.GetID(), "load-1111")
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, countLoad.Load(), int32(1))
+	})
+
+	t.Run("Тест 2. Загруженный объект находится в кэше как успешно выполненный", func(t *testing.T) {
+		entry, ok := cache.GetEntry("load-1111")
+		assert.True(t, ok)
+		assert.True(t, entry.IsCompletedSuccessfully)
+		assert.False(t, entry.TimeExpiry.IsZero())
+
+		_, err := cache.GetOrLoad(context.Background(), "load-1111", loader)
+		assert.NoError(t, err)
+		assert.Equal(t, countLoad.Load(), int32(1))
+
+		index, _ := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "")
+	})
+
+	t.Run("Тест 3. Ошибка загрузки не кэшируется", func(t *testing.T) {
+		errLoad := errors.New("load error")
+		_, err := cache.GetOrLoad(context.Background(), "load-2222", func(ctx context.Context) (*objectsmispformat.ListFormatsMISP, error) {
+			return nil, errLoad
+		})
+		assert.ErrorIs(t, err, errLoad)
+
+		_, ok := cache.GetObjectFromCacheByKey("load-2222")
+		assert.False(t, ok)
+	})
+
+	t.Run("Тест 4. Загруженный объект добавляется с учетом размера кэша", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](3))
+		assert.NoError(t, err)
+
+		var admitted atomic.Int32
+		cache.OnAdmit(func(string, *objectsmispformat.ListFormatsMISP, cachingstoragewithqueue.Reason) {
+			admitted.Add(1)
+		})
+
+		for _, key := range []string{"load-1111", "load-2222", "load-3333", "load-4444"} {
+			obj, err := cache.GetOrLoad(context.Background(), key, func(ctx context.Context) (*objectsmispformat.ListFormatsMISP, error) {
+				return &objectsmispformat.ListFormatsMISP{ID: key}, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, obj.GetID(), key)
+		}
+
+		assert.Equal(t, cache.GetCacheSize(), 3)
+		_, ok := cache.GetEntry("load-1111")
+		assert.False(t, ok)
+		_, ok = cache.GetEntry("load-4444")
+		assert.True(t, ok)
+
+		stats := cache.Stats()
+		assert.Equal(t, stats.Admitted, uint64(4))
+		assert.Equal(t, stats.EvictedBySize, uint64(1))
+		assert.Equal(t, admitted.Load(), int32(4))
+	})
+}
@@ -0,0 +1,160 @@
+package cachingstoragewithqueue_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// namedObjectForCache вспомогательный тип реализующий интерфейс NamedHandler
+type namedObjectForCache struct {
+	*examples.SpecialObjectForCache[*objectsmispformat.ListFormatsMISP]
+	handlerName string
+}
+
+func (o *namedObjectForCache) GetHandlerName() string {
+	return o.handlerName
+}
+
+func TestHandlerRegistry(t *testing.T) {
+	newObject := func(id, handlerName string) *namedObjectForCache {
+		return &namedObjectForCache{SpecialObjectForCache: newTestObject(id, "", false), handlerName: handlerName}
+	}
+
+	var (
+		mutex        sync.Mutex
+		listExecuted []string
+	)
+	mispUpload := func(id string, obj *objectsmispformat.ListFormatsMISP) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		listExecuted = append(listExecuted, id+"/"+obj.GetID())
+
+		return id != "fail"
+	}
+
+	t.Run("Тест 1. Проверка регистрации обработчиков", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithHandler("", mispUpload))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithHandler[*objectsmispformat.ListFormatsMISP]("misp-upload", nil))
+		assert.Error(t, err)
+
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithHandler("misp-upload", mispUpload))
+		assert.NoError(t, err)
+		assert.Error(t, cache.RegisterHandler("misp-upload", mispUpload))
+		assert.NoError(t, cache.RegisterHandler("misp-delete", mispUpload))
+	})
+
+	t.Run("Тест 2. Обработчик определяется по имени в момент выполнения", func(t *testing.T) {
+		listExecuted = nil
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](2))
+		assert.NoError(t, err)
+
+		assert.NoError(t, cache.AddObjectToCache("1111", newObject("1111", "misp-upload")))
+		entry, _ := cache.GetEntry("1111")
+		assert.Equal(t, entry.HandlerName, "misp-upload")
+
+		//обработчик регистрируется после добавления объекта в кэш
+		assert.NoError(t, cache.RegisterHandler("misp-upload", mispUpload))
+
+		f, ok := cache.GetFuncFromCacheByKey("1111")
+		assert.True(t, ok)
+		assert.True(t, f(0))
+		assert.Equal(t, listExecuted, []string{"1111/1111"})
+
+		index, f := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "1111")
+		assert.True(t, f(0))
+	})
+
+	t.Run("Тест 3. Объект без зарегистрированного обработчика выполняется неуспешно", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage[*objectsmispformat.ListFormatsMISP]()
+		assert.NoError(t, err)
+
+		assert.NoError(t, cache.AddObjectToCache("2222", newObject("2222", "unknown")))
+		f, ok := cache.GetFuncFromCacheByKey("2222")
+		assert.True(t, ok)
+		assert.False(t, f(0))
+	})
+
+	t.Run("Тест 4. Объекты без имени обработчика выполняются функцией из SetFunc", func(t *testing.T) {
+		listExecuted = nil
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithHandler("misp-upload", mispUpload))
+		assert.NoError(t, err)
+
+		obj := newObject("3333", "")
+		obj.SetFunc(func(int) bool { return false })
+		assert.NoError(t, cache.AddObjectToCache("3333", obj))
+
+		f, _ := cache.GetFuncFromCacheByKey("3333")
+		assert.False(t, f(0))
+		assert.Empty(t, listExecuted)
+	})
+
+	t.Run("Тест 5. Автоматическое выполнение объектов с именем обработчика", func(t *testing.T) {
+		listExecuted = nil
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](2),
+			cachingstoragewithqueue.WithHandler("misp-upload", mispUpload))
+		assert.NoError(t, err)
+
+		cache.PushObjectToQueue(newObject("4444", "misp-upload"))
+		cache.PushObjectToQueue(newObject("fail", "misp-upload"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.AsyncExecution_Test(ctx, nil)
+
+		assert.Eventually(t, func() bool {
+			return len(cache.GetIndexesWithIsExecutionStatus()) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		status, _ := cache.GetIsCompletedSuccessfully("4444")
+		assert.True(t, status)
+		status, _ = cache.GetIsCompletedSuccessfully("fail")
+		assert.False(t, status)
+	})
+
+	t.Run("Тест 6. Имя обработчика сохраняется в снимке состояния", func(t *testing.T) {
+		listExecuted = nil
+		factory := func(id string, obj *objectsmispformat.ListFormatsMISP) cachingstoragewithqueue.CacheStorageHandler[*objectsmispformat.ListFormatsMISP] {
+			return newObject(id, "")
+		}
+
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, factory))
+		assert.NoError(t, err)
+		assert.NoError(t, cache.AddObjectToCache("5555", newObject("5555", "misp-upload")))
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+
+		restored, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, factory),
+			cachingstoragewithqueue.WithHandler("misp-upload", mispUpload))
+		assert.NoError(t, err)
+		assert.NoError(t, restored.LoadSnapshot(buf))
+
+		f, ok := restored.GetFuncFromCacheByKey("5555")
+		assert.True(t, ok)
+		assert.True(t, f(0))
+		assert.Equal(t, listExecuted, []string{"5555/5555"})
+	})
+}
@@ -0,0 +1,76 @@
+package cachingstoragewithqueue_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// hashedObjectForCache вспомогательный тип реализующий интерфейс Hasher
+type hashedObjectForCache struct {
+	*examples.SpecialObjectForCache[*objectsmispformat.ListFormatsMISP]
+	content         string
+	countComparison *int
+}
+
+func (o *hashedObjectForCache) Hash() []byte {
+	h := sha256.Sum256([]byte(o.content))
+
+	return h[:]
+}
+
+func (o *hashedObjectForCache) Comparison(objFromCache *objectsmispformat.ListFormatsMISP) bool {
+	*o.countComparison++
+
+	return o.SpecialObjectForCache.Comparison(objFromCache)
+}
+
+func TestHasher(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	var countComparison int
+	newObject := func(content string) *hashedObjectForCache {
+		return &hashedObjectForCache{SpecialObjectForCache: newTestObject("hash-1111", "", true), content: content, countComparison: &countComparison}
+	}
+
+	assert.NoError(t, cache.AddObjectToCache("hash-1111", newObject("version 1")))
+
+	t.Run("Тест 1. Совпадение отпечатков означает идентичные объекты", func(t *testing.T) {
+		assert.Error(t, cache.AddObjectToCache("hash-1111", newObject("version 1")))
+		assert.Error(t, cache.AddObjectToCache("hash-1111", newObject("version 1")))
+
+		assert.Equal(t, countComparison, 0)
+		assert.Equal(t, cache.Stats().HashDuplicates, uint64(2))
+	})
+
+	t.Run("Тест 2. Разные отпечатки означают замену объекта без вызова Comparison", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("hash-1111", newObject("version 2")))
+
+		assert.Equal(t, countComparison, 0)
+		assert.Equal(t, cache.Stats().HashComparisonsSkipped, uint64(1))
+
+		//отпечаток нового объекта сохранён
+		assert.Error(t, cache.AddObjectToCache("hash-1111", newObject("version 2")))
+	})
+
+	t.Run("Тест 3. Без отпечатка выполняется Comparison", func(t *testing.T) {
+		soc := examples.NewSpecialObjectForCache[*objectsmispformat.ListFormatsMISP]()
+		objectTemplate := objectsmispformat.NewListFormatsMISP()
+		objectTemplate.ID = "hash-2222"
+		soc.SetID(objectTemplate.ID)
+		soc.SetObject(objectTemplate)
+		assert.NoError(t, cache.AddObjectToCache("hash-2222", soc))
+
+		obj := newObject("version 1")
+		obj.GetObject().ID = "hash-2222"
+		assert.Error(t, cache.AddObjectToCache("hash-2222", obj))
+		assert.Equal(t, countComparison, 1)
+	})
+}
@@ -0,0 +1,33 @@
+package cachingstoragewithqueue_test
+
+import (
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// newTestObject вспомогательный тип с объектом, у которого задан ключ id и описание
+// события info, функция объекта всегда возвращает isSuccess
+func newTestObject(id, info string, isSuccess bool) *examples.SpecialObjectForCache[*objectsmispformat.ListFormatsMISP] {
+	objectTemplate := objectsmispformat.NewListFormatsMISP()
+	objectTemplate.ID = id
+	objectTemplate.Event.Info = info
+
+	soc := examples.NewSpecialObjectForCache[*objectsmispformat.ListFormatsMISP]()
+	soc.SetID(id)
+	soc.SetObject(objectTemplate)
+	soc.SetFunc(func(int) bool { return isSuccess })
+
+	return soc
+}
+
+// testFactory восстановление вспомогательного типа с функцией-обёрткой выполнения из
+// снимка состояния или журнала упреждающей записи, функция объекта выполняется успешно
+func testFactory(id string, obj *objectsmispformat.ListFormatsMISP) cachingstoragewithqueue.CacheStorageHandler[*objectsmispformat.ListFormatsMISP] {
+	soc := examples.NewSpecialObjectForCache[*objectsmispformat.ListFormatsMISP]()
+	soc.SetID(id)
+	soc.SetObject(obj)
+	soc.SetFunc(func(int) bool { return true })
+
+	return soc
+}
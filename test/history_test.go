@@ -0,0 +1,107 @@
+package cachingstoragewithqueue_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestObjectHistory(t *testing.T) {
+	_, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithObjectHistory[*objectsmispformat.ListFormatsMISP](0, nil))
+	assert.Error(t, err)
+
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+		cachingstoragewithqueue.WithObjectHistory(2, func(obj *objectsmispformat.ListFormatsMISP) *objectsmispformat.ListFormatsMISP {
+			objCopy := *obj
+
+			return &objCopy
+		}))
+	assert.NoError(t, err)
+
+	//новые версии объекта отличаются атрибутами
+	newVersion := func(version int) cachingstoragewithqueue.CacheStorageHandler[*objectsmispformat.ListFormatsMISP] {
+		soc := examples.NewSpecialObjectForCache[*objectsmispformat.ListFormatsMISP]()
+		objectTemplate := objectsmispformat.NewListFormatsMISP()
+		objectTemplate.ID = "hist-1111"
+		for i := range version {
+			objectTemplate.Attributes = append(objectTemplate.Attributes, &objectsmispformat.AttributesMispFormat{Value: fmt.Sprint(i)})
+		}
+		soc.SetID(objectTemplate.ID)
+		soc.SetObject(objectTemplate)
+		soc.SetFunc(func(int) bool { return true })
+
+		return soc
+	}
+
+	t.Run("Тест 1. У нового объекта нет предыдущих версий", func(t *testing.T) {
+		_, ok := cache.GetObjectHistory("hist-1111")
+		assert.False(t, ok)
+
+		assert.NoError(t, cache.AddObjectToCache("hist-1111", newVersion(0)))
+		history, ok := cache.GetObjectHistory("hist-1111")
+		assert.True(t, ok)
+		assert.Empty(t, history)
+	})
+
+	t.Run("Тест 2. Идентичный объект не создает новую версию", func(t *testing.T) {
+		assert.Error(t, cache.AddObjectToCache("hist-1111", newVersion(0)))
+
+		history, _ := cache.GetObjectHistory("hist-1111")
+		assert.Empty(t, history)
+	})
+
+	t.Run("Тест 3. Хранится заданное количество последних версий", func(t *testing.T) {
+		for version := 1; version <= 3; version++ {
+			assert.NoError(t, cache.AddObjectToCache("hist-1111", newVersion(version)))
+		}
+
+		//MatchingAndReplacement в примере возвращает объект из кэша, поэтому все версии
+		//имеют нулевое количество атрибутов, главное их количество и порядок
+		history, ok := cache.GetObjectHistory("hist-1111")
+		assert.True(t, ok)
+		assert.Len(t, history, 2)
+		assert.False(t, history[0].Time.After(history[1].Time))
+		assert.Equal(t, history[1].Object.GetID(), "hist-1111")
+		assert.NotSame(t, history[0].Object, history[1].Object)
+	})
+
+	t.Run("Тест 4. Версии восстанавливаются из журнала упреждающей записи", func(t *testing.T) {
+		dir := t.TempDir()
+		newCache := func() *cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP] {
+			cache, err := cachingstoragewithqueue.NewCacheStorage(
+				cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+				cachingstoragewithqueue.WithObjectHistory[*objectsmispformat.ListFormatsMISP](2, nil),
+				cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory),
+				cachingstoragewithqueue.WithWAL[*objectsmispformat.ListFormatsMISP](dir, cachingstoragewithqueue.WALFsyncAlways, 1))
+			assert.NoError(t, err)
+
+			return cache
+		}
+
+		cache := newCache()
+		for version := range 3 {
+			assert.NoError(t, cache.AddObjectToCache("hist-1111", newVersion(version)))
+		}
+		before, _ := cache.GetObjectHistory("hist-1111")
+		assert.Len(t, before, 2)
+
+		//журнал не закрывается, что имитирует аварийное завершение
+		restored := newCache()
+		defer restored.Close()
+
+		after, ok := restored.GetObjectHistory("hist-1111")
+		assert.True(t, ok)
+		assert.Len(t, after, 2)
+		for i := range after {
+			assert.True(t, after[i].Time.Equal(before[i].Time))
+			assert.Equal(t, after[i].Object.GetID(), "hist-1111")
+		}
+	})
+}
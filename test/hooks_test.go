@@ -0,0 +1,105 @@
+package cachingstoragewithqueue_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// hookCall вызов функции обратного вызова
+type hookCall struct {
+	hook   string
+	id     string
+	info   string
+	reason cachingstoragewithqueue.Reason
+}
+
+func TestHooks(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	var (
+		mutex sync.Mutex
+		calls []hookCall
+	)
+
+	//функции обратного вызова обращаются к хранилищу, что возможно так как они
+	//вызываются после снятия блокировок
+	newHook := func(name string) cachingstoragewithqueue.HookFunc[*objectsmispformat.ListFormatsMISP] {
+		return func(id string, obj *objectsmispformat.ListFormatsMISP, reason cachingstoragewithqueue.Reason) {
+			cache.GetCacheSize()
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			calls = append(calls, hookCall{hook: name, id: id, info: obj.Event.Info, reason: reason})
+		}
+	}
+
+	lastCall := func() hookCall {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if len(calls) == 0 {
+			return hookCall{}
+		}
+
+		return calls[len(calls)-1]
+	}
+
+	cache.OnAdmit(newHook("admit"))
+	cache.OnDuplicate(newHook("duplicate"))
+	cache.OnReplace(newHook("replace"))
+	cache.OnSuccess(newHook("success"))
+	cache.OnFailure(newHook("failure"))
+	cache.OnEvict(newHook("evict"))
+	cache.OnEvict(nil)
+
+	t.Run("Тест 1. Добавление, дубликат и замена объекта", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", true)))
+		assert.Equal(t, lastCall(), hookCall{hook: "admit", id: "1111", info: "info", reason: cachingstoragewithqueue.ReasonNew})
+
+		assert.Error(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", true)))
+		assert.Equal(t, lastCall(), hookCall{hook: "duplicate", id: "1111", info: "info", reason: cachingstoragewithqueue.ReasonIdentical})
+
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "new info", true)))
+		call := lastCall()
+		assert.Equal(t, call.hook, "replace")
+		assert.Equal(t, call.reason, cachingstoragewithqueue.ReasonReplaced)
+	})
+
+	t.Run("Тест 2. Успешное и неуспешное выполнение", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "info", true)))
+
+		cache.ChangeExecution("1111")
+		cache.ChangeValues("1111", true)
+		assert.Equal(t, lastCall().hook, "success")
+		assert.Equal(t, lastCall().reason, cachingstoragewithqueue.ReasonSucceeded)
+
+		cache.ChangeExecution("2222")
+		cache.ChangeValues("2222", false)
+		assert.Equal(t, lastCall(), hookCall{hook: "failure", id: "2222", info: "info", reason: cachingstoragewithqueue.ReasonFailed})
+
+		//изменение статуса отсутствующего объекта не вызывает функций обратного вызова
+		cache.ChangeValues("9999", true)
+		assert.Equal(t, lastCall().id, "2222")
+	})
+
+	t.Run("Тест 3. Вытеснение объектов по времени жизни и по размеру кэша", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache_Test("3333", time.Now().Add(-time.Minute), newTestObject("3333", "info", true)))
+		cache.DeleteForTimeExpiryObjectFromCache()
+		assert.Equal(t, lastCall(), hookCall{hook: "evict", id: "3333", info: "info", reason: cachingstoragewithqueue.ReasonTtl})
+
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+		call := lastCall()
+		assert.Equal(t, call.hook, "evict")
+		assert.Equal(t, call.id, "1111")
+		assert.Equal(t, call.reason, cachingstoragewithqueue.ReasonSize)
+	})
+}
@@ -0,0 +1,144 @@
+package cachingstoragewithqueue_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// writerLoggingForTest WriterLoggingData запоминающий полученные сообщения
+type writerLoggingForTest struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (w *writerLoggingForTest) Write(msgType, msg string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.messages = append(w.messages, msgType+": "+msg)
+
+	return true
+}
+
+func (w *writerLoggingForTest) getMessages() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return append([]string(nil), w.messages...)
+}
+
+// syncBuffer буфер безопасный для одновременной записи
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	return sb.buf.String()
+}
+
+func TestSlogLogging(t *testing.T) {
+	t.Run("Тест 1. Структурированные записи о выполнении объектов", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithSlogLogger[*objectsmispformat.ListFormatsMISP](nil))
+		assert.Error(t, err)
+
+		buf := &syncBuffer{}
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](2),
+			cachingstoragewithqueue.WithSlogLogger[*objectsmispformat.ListFormatsMISP](slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+		assert.NoError(t, err)
+
+		cache.PushObjectToQueue(newTestObject("1111", "", true))
+		cache.PushObjectToQueue(newTestObject("2222", "", false))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.AsyncExecution_Test(ctx, nil)
+
+		assert.Eventually(t, func() bool {
+			return strings.Count(buf.String(), "\n") == 2
+		}, time.Second, 10*time.Millisecond)
+
+		records := map[string]map[string]any{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(line), &record))
+			records[record["id"].(string)] = record
+		}
+
+		assert.Equal(t, records["1111"]["event"], "executed")
+		assert.Equal(t, records["1111"]["level"], "DEBUG")
+		assert.Equal(t, records["1111"]["attempt"], float64(1))
+		assert.Contains(t, records["1111"], "duration")
+
+		assert.Equal(t, records["2222"]["event"], "execution_failed")
+		assert.Equal(t, records["2222"]["level"], "INFO")
+	})
+
+	t.Run("Тест 2. Адаптер WriterLoggingData в slog.Handler", func(t *testing.T) {
+		w := &writerLoggingForTest{}
+		logger := slog.New(cachingstoragewithqueue.NewSlogHandler(w, nil))
+
+		logger.Debug("skipped")
+		logger.With("id", "1111").WithGroup("object").Warn("rejected", "attempt", 2)
+
+		messages := w.getMessages()
+		assert.Len(t, messages, 1)
+		assert.True(t, strings.HasPrefix(messages[0], `warning: rejected id="1111" object.attempt="2" `))
+		assert.Contains(t, messages[0], "logging_test.go:")
+	})
+
+	t.Run("Тест 3. Адаптер slog.Handler в WriterLoggingData", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		w := cachingstoragewithqueue.NewWriterLoggingData(slog.NewJSONHandler(buf, nil))
+
+		assert.True(t, w.Write("debug", "skipped"))
+		assert.True(t, w.Write("error", "something went wrong"))
+
+		var record map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, record["level"], "ERROR")
+		assert.Equal(t, record["msg"], "something went wrong")
+		assert.Equal(t, record["msg_type"], "error")
+	})
+
+	t.Run("Тест 4. Без WithSlogLogger ошибки передаются в WriterLoggingData", func(t *testing.T) {
+		w := &writerLoggingForTest{}
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithLogging[*objectsmispformat.ListFormatsMISP](w))
+		assert.NoError(t, err)
+
+		obj := &namedObjectForCache{SpecialObjectForCache: newTestObject("3333", "", true), handlerName: "unknown"}
+		assert.NoError(t, cache.AddObjectToCache("3333", obj))
+		f, _ := cache.GetFuncFromCacheByKey("3333")
+		assert.False(t, f(0))
+
+		messages := w.getMessages()
+		assert.Len(t, messages, 1)
+		assert.True(t, strings.HasPrefix(messages[0], `error: cachingstoragewithqueue package event="handler_not_registered" id="3333" error=`))
+		assert.Contains(t, messages[0], "handlers.go:")
+	})
+}
@@ -0,0 +1,77 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+		cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](2))
+	assert.NoError(t, err)
+
+	for _, id := range []string{"1111", "2222"} {
+		soc := newTestObject(id, "", false)
+		soc.SetFunc(func(int) bool {
+			time.Sleep(20 * time.Millisecond)
+
+			return id == "1111"
+		})
+
+		cache.PushObjectToQueue(soc)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.AsyncExecution_Test(ctx, nil)
+
+	assert.Eventually(t, func() bool {
+		stats := cache.Stats()
+
+		return stats.Succeeded+stats.Failed == 2
+	}, time.Second, 10*time.Millisecond)
+
+	server := httptest.NewServer(cache.MetricsHandler(`misp "main"`))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	body := string(b)
+
+	t.Run("Тест 1. Тип содержимого и счетчики", func(t *testing.T) {
+		assert.Equal(t, res.Header.Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+
+		assert.Contains(t, body, "# TYPE cachingstoragewithqueue_pushed_total counter\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_pushed_total{instance="misp \"main\""} 2`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_succeeded_total{instance="misp \"main\""} 1`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_failed_total{instance="misp \"main\""} 1`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_cache_size{instance="misp \"main\""} 2`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_hash_duplicates_total{instance="misp \"main\""} 0`+"\n")
+	})
+
+	t.Run("Тест 2. Гистограммы времени выполнения, ожидания и попыток", func(t *testing.T) {
+		assert.Contains(t, body, "# TYPE cachingstoragewithqueue_handler_duration_seconds histogram\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_handler_duration_seconds_bucket{instance="misp \"main\"",le="0.01"} 0`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_handler_duration_seconds_bucket{instance="misp \"main\"",le="+Inf"} 2`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_handler_duration_seconds_count{instance="misp \"main\""} 2`+"\n")
+
+		assert.Contains(t, body, `cachingstoragewithqueue_queue_wait_seconds_count{instance="misp \"main\""} 2`+"\n")
+
+		assert.Contains(t, body, `cachingstoragewithqueue_attempts_succeeded_bucket{instance="misp \"main\"",le="1"} 1`+"\n")
+		assert.Contains(t, body, `cachingstoragewithqueue_attempts_failed_sum{instance="misp \"main\""} 1`+"\n")
+	})
+}
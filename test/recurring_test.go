@@ -0,0 +1,183 @@
+package cachingstoragewithqueue_test
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestRecurringObjects(t *testing.T) {
+	newCache := func() *cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP] {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](3),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory))
+		assert.NoError(t, err)
+
+		return cache
+	}
+
+	t.Run("Тест 1. Недопустимое расписание", func(t *testing.T) {
+		cache := newCache()
+		assert.Error(t, cache.AddRecurringObject(newTestObject("1111", "", true), 100*time.Millisecond))
+		assert.Error(t, cache.AddCronObject(newTestObject("1111", "", true), "61 * * * *"))
+		assert.Error(t, cache.AddCronObject(newTestObject("1111", "", true), "* * *"))
+		//30 февраля не наступает никогда
+		assert.Error(t, cache.AddCronObject(newTestObject("1111", "", true), "0 0 30 2 *"))
+
+		assert.NoError(t, cache.AddCronObject(newTestObject("1111", "", true), "@hourly"))
+		assert.ErrorIs(t, cache.AddRecurringObject(newTestObject("1111", "", true), time.Minute), cachingstoragewithqueue.ErrWrongState)
+
+		entry, ok := cache.GetEntry("1111")
+		assert.True(t, ok)
+		assert.Equal(t, entry.Schedule, "@hourly")
+		assert.Zero(t, entry.TimeNextRun.Minute())
+		assert.True(t, entry.TimeNextRun.After(time.Now()))
+	})
+
+	t.Run("Тест 2. Запуск по расписанию и счетчики попыток", func(t *testing.T) {
+		cache := newCache()
+		assert.NoError(t, cache.AddRecurringObject(newTestObject("1111", "", true), time.Second))
+
+		//до наступления времени запуска объект не выбирается для выполнения
+		index, _ := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Empty(t, index)
+
+		time.Sleep(1100 * time.Millisecond)
+		cache.StartRecurringRuns_Test()
+
+		index, _ = cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "1111")
+
+		//после всех неуспешных попыток запуск завершается
+		for range 3 {
+			cache.ChangeExecution("1111")
+			cache.ChangeValues("1111", false)
+		}
+
+		entry, _ := cache.GetEntry("1111")
+		assert.Equal(t, entry.NumberExecutionAttempts, 3)
+		assert.False(t, entry.TimeNextRun.IsZero())
+
+		index, _ = cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Empty(t, index)
+
+		//при очередном запуске количество попыток считается заново
+		assert.NoError(t, cache.RetryNow("1111"))
+		cache.ChangeExecution("1111")
+		cache.ChangeValues("1111", true)
+
+		entry, _ = cache.GetEntry("1111")
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+		assert.True(t, entry.IsCompletedSuccessfully)
+		assert.True(t, entry.TimeNextRun.After(time.Now()))
+
+		assert.ErrorIs(t, cache.Requeue("1111"), cachingstoragewithqueue.ErrWrongState)
+	})
+
+	t.Run("Тест 3. Периодические объекты не вытесняются из кэша", func(t *testing.T) {
+		cache := newCache()
+		assert.NoError(t, cache.AddRecurringObject(newTestObject("1111", "", true), time.Hour))
+		assert.NoError(t, cache.AddRecurringObject(newTestObject("2222", "", true), time.Hour))
+		assert.NoError(t, cache.AddObjectToCache("3333", newTestObject("3333", "", true)))
+		cache.ChangeExecution("3333")
+		cache.ChangeValues("3333", true)
+
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+		cache.DeleteForTimeExpiryObjectFromCache()
+
+		assert.Equal(t, cache.GetCacheSize(), 2)
+		_, ok := cache.GetEntry("3333")
+		assert.False(t, ok)
+
+		assert.NoError(t, cache.Cancel("1111"))
+		assert.Equal(t, cache.GetCacheSize(), 1)
+	})
+
+	t.Run("Тест 4. Сохранение расписания в снимке", func(t *testing.T) {
+		cache := newCache()
+		assert.NoError(t, cache.AddCronObject(newTestObject("1111", "", true), "30 3 * * *"))
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+
+		restored := newCache()
+		assert.NoError(t, restored.LoadSnapshot(buf))
+
+		before, _ := cache.GetEntry("1111")
+		after, ok := restored.GetEntry("1111")
+		assert.True(t, ok)
+		assert.Equal(t, after.Schedule, "30 3 * * *")
+		assert.True(t, after.TimeNextRun.Equal(before.TimeNextRun))
+
+		index, _ := restored.GetFuncFromCacheMinTimeExpiry()
+		assert.Empty(t, index)
+	})
+
+	t.Run("Тест 5. Автоматический запуск без пересечения запусков", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithTimeTick[*objectsmispformat.ListFormatsMISP](1),
+			cachingstoragewithqueue.WithEnableAsyncProcessing[*objectsmispformat.ListFormatsMISP](4))
+		assert.NoError(t, err)
+
+		var count, running, overlapped atomic.Int32
+		soc := newTestObject("1111", "", true)
+		soc.SetFunc(func(int) bool {
+			if running.Add(1) > 1 {
+				overlapped.Add(1)
+			}
+			defer running.Add(-1)
+
+			count.Add(1)
+			time.Sleep(1500 * time.Millisecond)
+
+			return true
+		})
+		assert.NoError(t, cache.AddRecurringObject(soc, time.Second))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cache.StartAutomaticExecution(ctx)
+
+		time.Sleep(5500 * time.Millisecond)
+
+		assert.GreaterOrEqual(t, count.Load(), int32(2))
+		assert.Equal(t, overlapped.Load(), int32(0))
+	})
+
+	t.Run("Тест 6. Добавление периодического объекта в заполненный кэш", func(t *testing.T) {
+		cache := newCache()
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "", true)))
+		cache.ChangeExecution("1111")
+		cache.ChangeValues("1111", true)
+		assert.NoError(t, cache.AddRecurringObject(newTestObject("2222", "", true), time.Hour))
+		assert.NoError(t, cache.AddRecurringObject(newTestObject("3333", "", true), time.Hour))
+
+		//успешно выполненный объект удаляется, освобождая место
+		assert.NoError(t, cache.AddRecurringObject(newTestObject("4444", "", true), time.Hour))
+		assert.Equal(t, cache.GetCacheSize(), 3)
+		_, ok := cache.GetEntry("1111")
+		assert.False(t, ok)
+
+		//периодические объекты не удаляются, поэтому место освободить нельзя
+		err := cache.AddCronObject(newTestObject("5555", "", true), "@hourly")
+		assert.ErrorIs(t, err, cachingstoragewithqueue.ErrCacheFull)
+		var entryErr *cachingstoragewithqueue.EntryError
+		assert.ErrorAs(t, err, &entryErr)
+		assert.Equal(t, cache.GetCacheSize(), 3)
+
+		//выполняющийся объект также не удаляется
+		assert.NoError(t, cache.Cancel("4444"))
+		assert.NoError(t, cache.AddObjectToCache("6666", newTestObject("6666", "", true)))
+		cache.ChangeExecution("6666")
+		assert.ErrorIs(t, cache.AddRecurringObject(newTestObject("7777", "", true), time.Hour), cachingstoragewithqueue.ErrCacheFull)
+		assert.Equal(t, cache.GetCacheSize(), 3)
+	})
+}
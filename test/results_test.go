@@ -0,0 +1,119 @@
+package cachingstoragewithqueue_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+// resultObjectForCache вспомогательный тип реализующий интерфейс ResultHandler
+type resultObjectForCache struct {
+	*examples.SpecialObjectForCache[*objectsmispformat.ListFormatsMISP]
+	resultFunc func(ctx context.Context) (any, bool)
+}
+
+func (o *resultObjectForCache) GetResultFunc() func(ctx context.Context) (any, bool) {
+	return o.resultFunc
+}
+
+// eventResult результат выполнения функции объекта
+type eventResult struct {
+	EventID string `json:"event_id"`
+	Status  int    `json:"status"`
+}
+
+func TestHandlerResults(t *testing.T) {
+	newObject := func(id string, result any, isSuccess bool) *resultObjectForCache {
+		return &resultObjectForCache{
+			SpecialObjectForCache: newTestObject(id, "", true),
+			resultFunc: func(context.Context) (any, bool) {
+				return result, isSuccess
+			},
+		}
+	}
+
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+		cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := cache.Subscribe(ctx, cachingstoragewithqueue.FilterByType[*objectsmispformat.ListFormatsMISP](cachingstoragewithqueue.EventSucceeded, cachingstoragewithqueue.EventFailed))
+	assert.NoError(t, err)
+
+	//выполнение функции объекта так же как при автоматической обработке
+	execute := func(key string) {
+		cache.ChangeExecution(key)
+		f, ok := cache.GetFuncFromCacheByKey(key)
+		assert.True(t, ok)
+		cache.ChangeValues(key, f(1))
+	}
+
+	t.Run("Тест 1. Сохранение результата выполнения", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("1111", newObject("1111", eventResult{EventID: "5521", Status: 200}, true)))
+
+		_, ok := cache.GetResult("1111")
+		assert.False(t, ok)
+
+		execute("1111")
+
+		result, ok := cache.GetResult("1111")
+		assert.True(t, ok)
+		assert.Equal(t, result, eventResult{EventID: "5521", Status: 200})
+
+		v, ok := cachingstoragewithqueue.GetResultAs[eventResult](cache, "1111")
+		assert.True(t, ok)
+		assert.Equal(t, v.EventID, "5521")
+
+		_, ok = cachingstoragewithqueue.GetResultAs[string](cache, "1111")
+		assert.False(t, ok)
+
+		entry, _ := cache.GetEntry("1111")
+		assert.Equal(t, entry.Result, eventResult{EventID: "5521", Status: 200})
+
+		event := <-ch
+		assert.Equal(t, event.Type, cachingstoragewithqueue.EventSucceeded)
+		assert.Equal(t, event.Result, eventResult{EventID: "5521", Status: 200})
+	})
+
+	t.Run("Тест 2. Результат неуспешного выполнения", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache("2222", newObject("2222", "service unavailable", false)))
+		execute("2222")
+
+		v, ok := cachingstoragewithqueue.GetResultAs[string](cache, "2222")
+		assert.True(t, ok)
+		assert.Equal(t, v, "service unavailable")
+
+		event := <-ch
+		assert.Equal(t, event.Type, cachingstoragewithqueue.EventFailed)
+		assert.Equal(t, event.Result, "service unavailable")
+	})
+
+	t.Run("Тест 3. Сохранение результата в снимке", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+
+		restored, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory))
+		assert.NoError(t, err)
+		assert.NoError(t, restored.LoadSnapshot(buf))
+
+		result, ok := restored.GetResult("1111")
+		assert.True(t, ok)
+		assert.IsType(t, json.RawMessage{}, result)
+
+		v, ok := cachingstoragewithqueue.GetResultAs[eventResult](restored, "1111")
+		assert.True(t, ok)
+		assert.Equal(t, v, eventResult{EventID: "5521", Status: 200})
+	})
+}
@@ -0,0 +1,125 @@
+package cachingstoragewithqueue_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestShards(t *testing.T) {
+	t.Run("Тест 1. Проверка допустимого количества сегментов", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](0))
+		assert.Error(t, err)
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](257))
+		assert.Error(t, err)
+	})
+
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](300),
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](50),
+		cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](8))
+	assert.NoError(t, err)
+
+	now := time.Now()
+	//объекты распределяются по разным сегментам, время жизни объекта с номером 0
+	//наименьшее, объекты с номерами 0-4 уже просрочены
+	for i := 19; i >= 0; i-- {
+		id := fmt.Sprintf("shard-%02d", i)
+		timeExpiry := now.Add(time.Duration(i) * time.Minute)
+		if i < 5 {
+			timeExpiry = now.Add(time.Duration(i-5) * time.Second)
+		}
+
+		assert.NoError(t, cache.AddObjectToCache_Test(id, timeExpiry, newTestObject(id, "", true)))
+	}
+
+	t.Run("Тест 2. Глобальные операции учитывают все сегменты", func(t *testing.T) {
+		assert.Equal(t, cache.GetCacheSize(), 20)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "shard-00")
+
+		cache.SetIsExecutionTrue("shard-00")
+		index, _ := cache.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "shard-01")
+		assert.Equal(t, len(cache.GetIndexesWithIsExecutionStatus()), 1)
+	})
+
+	t.Run("Тест 3. Удаление самого старого объекта среди всех сегментов", func(t *testing.T) {
+		//самый старый объект выполняется, удалить его нельзя
+		assert.Error(t, cache.DeleteOldestObjectFromCache())
+
+		cache.ChangeValues("shard-00", true)
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+		assert.Equal(t, cache.GetCacheSize(), 19)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "shard-01")
+	})
+
+	t.Run("Тест 4. Удаление объектов с истекшим временем жизни во всех сегментах", func(t *testing.T) {
+		cache.DeleteForTimeExpiryObjectFromCache()
+		assert.Equal(t, cache.GetCacheSize(), 15)
+		assert.Equal(t, cache.GetOldestObjectFromCache(), "shard-05")
+
+		cache.CleanCache()
+		assert.Equal(t, cache.GetCacheSize(), 0)
+	})
+}
+
+// BenchmarkShards сравнивает производительность кэша с разным количеством сегментов при
+// большом количестве одновременных читателей и писателей
+func BenchmarkShards(b *testing.B) {
+	const size = 1000
+
+	keys := make([]string, 0, size)
+	for i := range size {
+		keys = append(keys, fmt.Sprintf("bench-%04d", i))
+	}
+
+	for _, shards := range []int{1, 4, 16, 64} {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxTtl[*objectsmispformat.ListFormatsMISP](3600),
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](size),
+			cachingstoragewithqueue.WithShards[*objectsmispformat.ListFormatsMISP](shards))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, key := range keys {
+			if err := cache.AddObjectToCache(key, newTestObject(key, "", true)); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Run(fmt.Sprintf("readers_%d_shards", shards), func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					cache.GetObjectFromCacheByKey(keys[rand.IntN(size)])
+				}
+			})
+		})
+
+		b.Run(fmt.Sprintf("readers_and_writers_%d_shards", shards), func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					key := keys[rand.IntN(size)]
+
+					//на каждые 4 чтения приходится одна запись
+					switch rand.IntN(5) {
+					case 0:
+						cache.ChangeExecution(key)
+						cache.ChangeValues(key, false)
+					default:
+						cache.GetObjectFromCacheByKey(key)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package cachingstoragewithqueue_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/cachingstoragewithqueue/examples"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestSnapshot(t *testing.T) {
+	//восстановление вспомогательного типа с функцией-обёрткой выполнения
+	var countFactory int
+	factory := func(id string, obj *objectsmispformat.ListFormatsMISP) cachingstoragewithqueue.CacheStorageHandler[*objectsmispformat.ListFormatsMISP] {
+		countFactory++
+
+		soc := examples.NewSpecialObjectForCache[*objectsmispformat.ListFormatsMISP]()
+		soc.SetID(id)
+		soc.SetObject(obj)
+		soc.SetFunc(func(int) bool { return true })
+
+		return soc
+	}
+
+	newCache := func() *cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP] {
+		cache, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, factory))
+		assert.NoError(t, err)
+
+		return cache
+	}
+
+	t.Run("Тест 1. Без Codec сохранение состояния невозможно", func(t *testing.T) {
+		cache, err := cachingstoragewithqueue.NewCacheStorage[*objectsmispformat.ListFormatsMISP]()
+		assert.NoError(t, err)
+		assert.Error(t, cache.SaveSnapshot(&bytes.Buffer{}))
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithAutoSnapshot[*objectsmispformat.ListFormatsMISP](filepath.Join(t.TempDir(), "snapshot.json"), 10))
+		assert.Error(t, err)
+	})
+
+	t.Run("Тест 2. Сохранение и восстановление очереди и кэша", func(t *testing.T) {
+		cache := newCache()
+		cache.PushObjectToQueue(newTestObject("queue-1111", "", true))
+		cache.PushObjectToQueue(newTestObject("queue-2222", "", true))
+		assert.NoError(t, cache.AddObjectToCache("cache-1111", newTestObject("cache-1111", "", true)))
+		assert.NoError(t, cache.AddObjectToCache("cache-2222", newTestObject("cache-2222", "", true)))
+
+		cache.ChangeExecution("cache-1111")
+		cache.ChangeValues("cache-1111", true)
+		cache.ChangeExecution("cache-2222")
+		cache.ChangeValues("cache-2222", false)
+		//выполняющийся объект восстанавливается как невыполняющийся
+		cache.ChangeExecution("cache-2222")
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+
+		countFactory = 0
+		restored := newCache()
+		assert.NoError(t, restored.LoadSnapshot(buf))
+		assert.Equal(t, countFactory, 4)
+
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 2)
+		obj, isEmpty := restored.PullObjectFromQueue()
+		assert.False(t, isEmpty)
+		assert.Equal(t, obj.GetID(), "queue-1111")
+		assert.True(t, obj.GetFunc()(0))
+
+		assert.Equal(t, restored.GetCacheSize(), 2)
+		for _, key := range []string{"cache-1111", "cache-2222"} {
+			before, _ := cache.GetEntry(key)
+			after, ok := restored.GetEntry(key)
+			assert.True(t, ok)
+			assert.Equal(t, after.Object.GetID(), key)
+			assert.Equal(t, after.IsCompletedSuccessfully, before.IsCompletedSuccessfully)
+			assert.Equal(t, after.NumberExecutionAttempts, before.NumberExecutionAttempts)
+			assert.True(t, after.TimeMain.Equal(before.TimeMain))
+			assert.True(t, after.TimeExpiry.Equal(before.TimeExpiry))
+			assert.False(t, after.IsExecution)
+		}
+
+		entry, _ := restored.GetEntry("cache-2222")
+		assert.Error(t, entry.LastError)
+
+		//функция-обёртка восстановлена
+		index, f := restored.GetFuncFromCacheMinTimeExpiry()
+		assert.Equal(t, index, "cache-2222")
+		assert.True(t, f(0))
+	})
+
+	t.Run("Тест 3. Автоматическое сохранение состояния в файл", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.json")
+		newAutoCache := func() *cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP] {
+			cache, err := cachingstoragewithqueue.NewCacheStorage(
+				cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+				cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, factory),
+				cachingstoragewithqueue.WithAutoSnapshot[*objectsmispformat.ListFormatsMISP](path, 1))
+			assert.NoError(t, err)
+
+			return cache
+		}
+
+		cache := newAutoCache()
+		ctx, ctxClose := context.WithCancel(context.Background())
+		cache.StartAutomaticExecution(ctx)
+		cache.PushObjectToQueue(newTestObject("auto-1111", "", true))
+		assert.NoError(t, cache.AddObjectToCache("auto-2222", newTestObject("auto-2222", "", true)))
+
+		//при завершении контекста состояние сохраняется последний раз
+		ctxClose()
+		time.Sleep(200 * time.Millisecond)
+
+		restored := newAutoCache()
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 1)
+		_, ok := restored.GetObjectFromCacheByKey("auto-2222")
+		assert.True(t, ok)
+	})
+
+	t.Run("Тест 4. Снимок больше максимального размера кэша не восстанавливается", func(t *testing.T) {
+		cache := newCache()
+		for i := range 5 {
+			assert.NoError(t, cache.AddObjectToCache(fmt.Sprintf("size-%d", i), newTestObject(fmt.Sprintf("size-%d", i), "", true)))
+		}
+		cache.PushObjectToQueue(newTestObject("queue-1111", "", true))
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+		data := buf.Bytes()
+
+		restored, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](4),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, factory))
+		assert.NoError(t, err)
+		assert.Error(t, restored.LoadSnapshot(bytes.NewReader(data)))
+		assert.Equal(t, restored.GetCacheSize(), 0)
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 0)
+
+		//объекты с совпадающими ключами заменяются и размер кэша не увеличивают
+		restored = newCache()
+		for i := range 5 {
+			assert.NoError(t, restored.AddObjectToCache(fmt.Sprintf("size-%d", i), newTestObject(fmt.Sprintf("size-%d", i), "", true)))
+			assert.NoError(t, restored.AddObjectToCache(fmt.Sprintf("other-%d", i), newTestObject(fmt.Sprintf("other-%d", i), "", true)))
+		}
+		assert.NoError(t, restored.LoadSnapshot(bytes.NewReader(data)))
+		assert.Equal(t, restored.GetCacheSize(), 10)
+
+		restored = newCache()
+		for i := range 6 {
+			assert.NoError(t, restored.AddObjectToCache(fmt.Sprintf("other-%d", i), newTestObject(fmt.Sprintf("other-%d", i), "", true)))
+		}
+		assert.Error(t, restored.LoadSnapshot(bytes.NewReader(data)))
+		assert.Equal(t, restored.GetCacheSize(), 6)
+	})
+
+	t.Run("Тест 5. Невыполненные объекты из снимка выполняются асинхронным обработчиком", func(t *testing.T) {
+		cache := newCache()
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "", true)))
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "", true)))
+		//функция объекта выполняется в момент сохранения
+		cache.ChangeExecution("2222")
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, cache.SaveSnapshot(buf))
+
+		restored := newCache()
+		assert.NoError(t, restored.LoadSnapshot(buf))
+		assert.NoError(t, restored.SetConcurrency(2))
+
+		restored.AsyncExecution_Test(context.Background(), nil)
+
+		assert.Eventually(t, func() bool {
+			return len(restored.GetIndexesWithIsCompletedSuccessfully()) == 2
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
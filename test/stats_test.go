@@ -0,0 +1,62 @@
+package cachingstoragewithqueue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestStats(t *testing.T) {
+	cache, err := cachingstoragewithqueue.NewCacheStorage(
+		cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10))
+	assert.NoError(t, err)
+
+	t.Run("Тест 1. Добавление в очередь и в кэш", func(t *testing.T) {
+		cache.PushObjectToQueue(newTestObject("1111", "info", true))
+		cache.PushObjectToQueue(newTestObject("2222", "info", true))
+
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", true)))
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "info", true)))
+		assert.Error(t, cache.AddObjectToCache("1111", newTestObject("1111", "info", true)))
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "new info", true)))
+
+		stats := cache.Stats()
+		assert.Equal(t, stats.Pushed, uint64(2))
+		assert.Equal(t, stats.QueueLength, 2)
+		assert.Equal(t, stats.Admitted, uint64(2))
+		assert.Equal(t, stats.DuplicatesRejected, uint64(1))
+		assert.Equal(t, stats.Replaced, uint64(1))
+		assert.Equal(t, stats.CacheSize, 2)
+	})
+
+	t.Run("Тест 2. Выполнение функций", func(t *testing.T) {
+		cache.ChangeExecution("1111")
+		cache.ChangeExecution("2222")
+		assert.Equal(t, cache.Stats().Running, 2)
+
+		cache.ChangeValues("1111", true)
+		cache.ChangeValues("2222", false)
+
+		stats := cache.Stats()
+		assert.Equal(t, stats.Running, 0)
+		assert.Equal(t, stats.Executed, uint64(2))
+		assert.Equal(t, stats.Succeeded, uint64(1))
+		assert.Equal(t, stats.Failed, uint64(1))
+	})
+
+	t.Run("Тест 3. Вытеснение объектов по времени жизни и по размеру кэша", func(t *testing.T) {
+		assert.NoError(t, cache.AddObjectToCache_Test("3333", time.Now().Add(-time.Minute), newTestObject("3333", "info", true)))
+		cache.DeleteForTimeExpiryObjectFromCache()
+
+		assert.NoError(t, cache.DeleteOldestObjectFromCache())
+
+		stats := cache.Stats()
+		assert.Equal(t, stats.EvictedByTtl, uint64(1))
+		assert.Equal(t, stats.EvictedBySize, uint64(1))
+		assert.Equal(t, stats.CacheSize, 1)
+	})
+}
@@ -0,0 +1,210 @@
+package cachingstoragewithqueue_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/av-belyakov/cachingstoragewithqueue"
+	"github.com/av-belyakov/objectsmispformat"
+)
+
+func TestWAL(t *testing.T) {
+	newCache := func(dir string, segmentSize int) (*cachingstoragewithqueue.CacheStorageWithQueue[*objectsmispformat.ListFormatsMISP], error) {
+		return cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](10),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory),
+			cachingstoragewithqueue.WithWAL[*objectsmispformat.ListFormatsMISP](dir, cachingstoragewithqueue.WALFsyncAlways, segmentSize))
+	}
+
+	t.Run("Тест 1. Журнал требует опцию WithSnapshot и допустимые параметры", func(t *testing.T) {
+		_, err := cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithWAL[*objectsmispformat.ListFormatsMISP](t.TempDir(), cachingstoragewithqueue.WALFsyncAlways, 1))
+		assert.Error(t, err)
+
+		_, err = newCache(t.TempDir(), 0)
+		assert.Error(t, err)
+
+		_, err = newCache("", 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Тест 2. Очередь и кэш восстанавливаются из журнала после сбоя", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := newCache(dir, 1)
+		assert.NoError(t, err)
+
+		cache.PushObjectToQueue(newTestObject("1111", "", true))
+		cache.PushObjectToQueue(newTestObject("2222", "", true))
+		cache.PushObjectToQueue(newTestObject("3333", "", true))
+		cache.PushObjectToQueue(newTestObject("4444", "", true))
+
+		//объект забирается из очереди и добавляется в кэш
+		obj, _ := cache.PullObjectFromQueue()
+		assert.NoError(t, cache.AddObjectToCache(obj.GetID(), obj))
+		cache.ChangeExecution(obj.GetID())
+		cache.ChangeValues(obj.GetID(), true)
+
+		obj, _ = cache.PullObjectFromQueue()
+		assert.NoError(t, cache.AddObjectToCache(obj.GetID(), obj))
+		cache.ChangeExecution(obj.GetID())
+		cache.ChangeValues(obj.GetID(), false)
+
+		//объект забран из очереди, но не добавлен в кэш до сбоя, он должен вернуться в очередь
+		_, _ = cache.PullObjectFromQueue()
+
+		//журнал не закрывается, что имитирует аварийное завершение
+		restored, err := newCache(dir, 1)
+		assert.NoError(t, err)
+		defer restored.Close()
+
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 2)
+		obj, _ = restored.PullObjectFromQueue()
+		assert.Equal(t, obj.GetID(), "3333")
+		assert.True(t, obj.GetFunc()(0))
+
+		assert.Equal(t, restored.GetCacheSize(), 2)
+		entry, ok := restored.GetEntry("1111")
+		assert.True(t, ok)
+		assert.True(t, entry.IsCompletedSuccessfully)
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+
+		entry, ok = restored.GetEntry("2222")
+		assert.True(t, ok)
+		assert.False(t, entry.IsCompletedSuccessfully)
+		assert.Equal(t, entry.NumberExecutionAttempts, 1)
+		assert.Error(t, entry.LastError)
+
+		f, ok := restored.GetFuncFromCacheByKey("2222")
+		assert.True(t, ok)
+		assert.True(t, f(0))
+	})
+
+	t.Run("Тест 3. Удаление и очистка фиксируются в журнале", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := newCache(dir, 1)
+		assert.NoError(t, err)
+
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "", true)))
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "", true)))
+		cache.DeleteByKeys("1111")
+		cache.PushObjectToQueue(newTestObject("3333", "", true))
+		cache.CleanQueue()
+		assert.NoError(t, cache.Close())
+
+		restored, err := newCache(dir, 1)
+		assert.NoError(t, err)
+		defer restored.Close()
+
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 0)
+		assert.Equal(t, restored.GetCacheSize(), 1)
+		_, ok := restored.GetEntry("2222")
+		assert.True(t, ok)
+	})
+
+	t.Run("Тест 4. Поврежденная последняя запись журнала пропускается", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := newCache(dir, 1)
+		assert.NoError(t, err)
+
+		cache.PushObjectToQueue(newTestObject("1111", "", true))
+		cache.PushObjectToQueue(newTestObject("2222", "", true))
+		assert.NoError(t, cache.Close())
+
+		//обрезка последней записи, как при сбое во время записи
+		segments, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+		assert.NoError(t, err)
+		last := segments[len(segments)-1]
+		info, err := os.Stat(last)
+		assert.NoError(t, err)
+		assert.NoError(t, os.Truncate(last, info.Size()-5))
+
+		restored, err := newCache(dir, 1)
+		assert.NoError(t, err)
+		defer restored.Close()
+
+		assert.Equal(t, restored.GetSizeObjectToQueue(), 1)
+		obj, _ := restored.PullObjectFromQueue()
+		assert.Equal(t, obj.GetID(), "1111")
+	})
+
+	t.Run("Тест 5. Сжатие журнала удаляет старые сегменты", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := newCache(dir, 1)
+		assert.NoError(t, err)
+		defer cache.Close()
+
+		//записи объемом больше нескольких сегментов
+		objectTemplate := objectsmispformat.NewListFormatsMISP()
+		objectTemplate.ID = "big"
+		objectTemplate.Event.Info = strings.Repeat("a", 300_000)
+		for range 10 {
+			cache.PushObjectToQueue(testFactory("big", objectTemplate))
+			cache.PullObjectFromQueue()
+		}
+
+		segments, err := filepath.Glob(filepath.Join(dir, "wal-*.log"))
+		assert.NoError(t, err)
+		assert.Greater(t, len(segments), 2)
+
+		assert.NoError(t, cache.CompactWAL())
+
+		segments, err = filepath.Glob(filepath.Join(dir, "wal-*.log"))
+		assert.NoError(t, err)
+		assert.Equal(t, len(segments), 1)
+
+		checkpoints, err := filepath.Glob(filepath.Join(dir, "wal-*.checkpoint"))
+		assert.NoError(t, err)
+		assert.Equal(t, len(checkpoints), 1)
+	})
+
+	t.Run("Тест 6. Кэш больше максимального размера из журнала не восстанавливается", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := newCache(dir, 1)
+		assert.NoError(t, err)
+
+		for _, id := range []string{"1111", "2222", "3333", "4444"} {
+			assert.NoError(t, cache.AddObjectToCache(id, newTestObject(id, "", true)))
+		}
+		assert.NoError(t, cache.Close())
+
+		_, err = cachingstoragewithqueue.NewCacheStorage(
+			cachingstoragewithqueue.WithMaxSize[*objectsmispformat.ListFormatsMISP](3),
+			cachingstoragewithqueue.WithSnapshot(cachingstoragewithqueue.JSONCodec[*objectsmispformat.ListFormatsMISP]{}, testFactory),
+			cachingstoragewithqueue.WithWAL[*objectsmispformat.ListFormatsMISP](dir, cachingstoragewithqueue.WALFsyncAlways, 1))
+		assert.Error(t, err)
+
+		//журнал не изменяется и восстанавливается при достаточном размере кэша
+		restored, err := newCache(dir, 1)
+		assert.NoError(t, err)
+		defer restored.Close()
+		assert.Equal(t, restored.GetCacheSize(), 4)
+	})
+
+	t.Run("Тест 7. Невыполненные объекты из журнала выполняются асинхронным обработчиком", func(t *testing.T) {
+		dir := t.TempDir()
+		cache, err := newCache(dir, 1)
+		assert.NoError(t, err)
+
+		//функция объекта '1111' не запускалась, функция объекта '2222' выполнялась во время сбоя
+		assert.NoError(t, cache.AddObjectToCache("1111", newTestObject("1111", "", true)))
+		assert.NoError(t, cache.AddObjectToCache("2222", newTestObject("2222", "", true)))
+		cache.ChangeExecution("2222")
+
+		restored, err := newCache(dir, 1)
+		assert.NoError(t, err)
+		defer restored.Close()
+		assert.NoError(t, restored.SetConcurrency(2))
+
+		restored.AsyncExecution_Test(context.Background(), nil)
+
+		assert.Eventually(t, func() bool {
+			return len(restored.GetIndexesWithIsCompletedSuccessfully()) == 2
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
@@ -1,33 +1,387 @@
 package cachingstoragewithqueue
 
 import (
+	"context"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/cron"
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/singleflight"
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/wal"
 )
 
 // CacheStorageWithQueue кэш объектов с очередью
 type CacheStorageWithQueue[T any] struct {
-	queue    queueObjects[T]   //очередь объектов предназначенных для выполнения
-	cache    cacheStorages[T]  //кеш хранилища обработанных объектов
-	logging  WriterLoggingData //логирование данных
-	maxTtl   time.Duration     //максимальное время, в секундах, по истечении которого запись в cacheStorages будет удалена
-	timeTick time.Duration     //интервал, в секундах, с которым будут выполнятся автоматические действия
-	isAsync  int               //включить асинхронное выполнение заданий в кэше
+	queue    queueObjects[T]       //очередь объектов предназначенных для выполнения
+	cache    cacheStorages[T]      //кеш хранилища обработанных объектов
+	loads    singleflight.Group[T] //загрузка объектов отсутствующих в кэше методом GetOrLoad
+	logging  WriterLoggingData     //логирование данных
+	logger   *slog.Logger          //структурированное логирование
+	maxTtl   atomic.Int64          //максимальное время, по истечении которого запись в cacheStorages будет удалена
+	timeTick atomic.Int64          //интервал, с которым будут выполнятся автоматические действия
+	isAsync  atomic.Int64          //включить асинхронное выполнение заданий в кэше
+	config   configControl         //изменение параметров хранилища во время работы
+	history  historyOptions[T]     //параметры хранения предыдущих версий объектов
+	dedup    dedupWindow           //окно дедупликации успешно выполненных объектов удалённых из кэша
+	dead     *deadLetterQueue[T]   //очередь недоставленных объектов
+	snapshot snapshotOptions[T]    //параметры сохранения и восстановления состояния хранилища
+	wal      *wal.Log              //журнал упреждающей записи
+	walOpts  walOptions            //параметры журнала упреждающей записи
+	backend  backendOptions[T]     //параметры хранилища объектов кэша
+	handlers handlerRegistry[T]    //обработчики объектов зарегистрированные по имени
+	stats    statistics            //счетчики статистики работы хранилища
+	metrics  metricsCollector      //гистограммы для метрик в формате Prometheus
+	hooks    lifecycleHooks[T]     //функции обратного вызова событий жизненного цикла объектов
+	subs     eventSubscribers[T]   //подписчики на события жизненного цикла объектов
+	running  executionRegistry     //контексты выполняющихся функций объектов
+	batch    batchOptions[T]       //параметры группового выполнения объектов
+	adaptive adaptiveConcurrency   //параметры адаптивного управления количеством потоков
+}
+
+// statistics счетчики статистики работы хранилища
+type statistics struct {
+	pushed             atomic.Uint64
+	admitted           atomic.Uint64
+	duplicatesRejected atomic.Uint64
+	replaced           atomic.Uint64
+	executed           atomic.Uint64
+	succeeded          atomic.Uint64
+	failed             atomic.Uint64
+	evictedByTtl       atomic.Uint64
+	evictedBySize      atomic.Uint64
+	eventsDropped      atomic.Uint64
+	//сравнения объектов по отпечаткам
+	hashDuplicates         atomic.Uint64
+	hashComparisonsSkipped atomic.Uint64
+}
+
+// Stats статистика работы хранилища
+type Stats struct {
+	//количество объектов добавленных в очередь
+	Pushed uint64
+	//количество новых объектов добавленных в кэш
+	Admitted uint64
+	//количество объектов не добавленных в кэш как идентичные объектам в кэше или
+	//ранее успешно выполненным объектам из окна дедупликации
+	DuplicatesRejected uint64
+	//количество объектов в кэше замененных с помощью MatchingAndReplacement
+	Replaced uint64
+	//количество запусков функций объектов
+	Executed uint64
+	//количество успешных выполнений функций объектов
+	Succeeded uint64
+	//количество неуспешных выполнений функций объектов
+	Failed uint64
+	//количество объектов удаленных из кэша по истечении времени жизни
+	EvictedByTtl uint64
+	//количество объектов удаленных из кэша при достижении максимального размера кэша
+	EvictedBySize uint64
+	//количество событий отброшенных из-за заполненного буфера подписчика
+	EventsDropped uint64
+	//количество дубликатов обнаруженных по совпадению отпечатков
+	HashDuplicates uint64
+	//количество замен объектов выполненных по различию отпечатков без вызова Comparison
+	HashComparisonsSkipped uint64
+	//текущая длина очереди, включая отложенные объекты
+	QueueLength int
+	//количество объектов в очереди время выполнения которых еще не наступило
+	QueueDelayed int
+	//текущий размер кэша
+	CacheSize int
+	//количество объектов функции которых выполняются в настоящее время
+	Running int
+	//текущее максимальное количество одновременно выполняемых функций, при адаптивном
+	//управлении изменяется в зависимости от времени выполнения и неуспешных выполнений
+	ConcurrencyLimit int
+}
+
+// Reason причина события жизненного цикла объекта
+type Reason string
+
+const (
+	//объект впервые добавлен в кэш
+	ReasonNew Reason = "new"
+	//объект полностью идентичен объекту в кэше, проверено методом Comparison
+	ReasonIdentical Reason = "identical"
+	//отпечаток объекта совпадает с отпечатком объекта в кэше
+	ReasonIdenticalHash Reason = "identical_hash"
+	//объект с таким ключом уже был успешно выполнен и находится в окне дедупликации
+	ReasonAlreadyProcessed Reason = "already_processed"
+	//объект в кэше заменен результатом MatchingAndReplacement
+	ReasonReplaced Reason = "replaced"
+	//начато очередное выполнение функции объекта
+	ReasonStarted Reason = "started"
+	//функция объекта выполнена успешно
+	ReasonSucceeded Reason = "succeeded"
+	//функция объекта выполнена неуспешно
+	ReasonFailed Reason = "failed"
+	//истекло время жизни объекта
+	ReasonTtl Reason = "ttl"
+	//достигнут максимальный размер кэша
+	ReasonSize Reason = "size"
+)
+
+// HookFunc функция обратного вызова, вызываемая при событии жизненного цикла объекта,
+// принимает ключ объекта, сам объект и причину события
+type HookFunc[T any] func(id string, obj T, reason Reason)
+
+// EventType тип события жизненного цикла объекта
+type EventType string
+
+const (
+	//объект добавлен в кэш
+	EventAdmitted EventType = "admitted"
+	//объект отклонён как дубликат
+	EventDuplicate EventType = "duplicate"
+	//объект в кэше заменен
+	EventReplaced EventType = "replaced"
+	//начато выполнение функции объекта
+	EventStarted EventType = "started"
+	//функция объекта выполнена успешно
+	EventSucceeded EventType = "succeeded"
+	//функция объекта выполнена неуспешно
+	EventFailed EventType = "failed"
+	//объект вытеснен из кэша при достижении максимального размера кэша
+	EventEvicted EventType = "evicted"
+	//объект удалён из кэша по истечении времени жизни
+	EventExpired EventType = "expired"
+)
+
+// Event событие жизненного цикла объекта
+type Event[T any] struct {
+	//время события
+	Time time.Time
+	//объект
+	Object T
+	//результат выполнения функции объекта, для событий EventSucceeded и EventFailed
+	Result any
+	//ключ объекта
+	ID string
+	//тип события
+	Type EventType
+	//причина события
+	Reason Reason
+}
+
+// lifecycleHooks функции обратного вызова событий жизненного цикла объектов
+type lifecycleHooks[T any] struct {
+	mutex sync.RWMutex
+	hooks map[EventType][]HookFunc[T]
+}
+
+// DeliveryPolicy политика доставки событий подписчику, буфер которого заполнен
+type DeliveryPolicy int
+
+const (
+	//событие отбрасывается, используется по умолчанию
+	DeliveryDrop DeliveryPolicy = iota
+	//отправитель ожидает освобождения буфера или завершения контекста подписки
+	DeliveryBlock
+)
+
+// SubscribeOption параметр подписки на события
+type SubscribeOption func(*subscriptionSettings) error
+
+// subscriptionSettings параметры подписки на события
+type subscriptionSettings struct {
+	bufferSize int
+	policy     DeliveryPolicy
+}
+
+// eventSubscriber подписчик на события жизненного цикла объектов
+type eventSubscriber[T any] struct {
+	ctx    context.Context
+	ch     chan Event[T]
+	filter func(Event[T]) bool
+	policy DeliveryPolicy
+	//блокировка отправки событий и закрытия канала
+	mutex  sync.Mutex
+	closed bool
+}
+
+// eventSubscribers подписчики на события жизненного цикла объектов
+type eventSubscribers[T any] struct {
+	mutex       sync.RWMutex
+	subscribers map[*eventSubscriber[T]]struct{}
+}
+
+// HandlerFunc обработчик объектов, регистрируемый по имени методом RegisterHandler,
+// принимает ключ и объект, возвращает успешность выполнения
+type HandlerFunc[T any] func(id string, obj T) bool
+
+// handlerRegistry обработчики объектов зарегистрированные по имени
+type handlerRegistry[T any] struct {
+	mutex    sync.RWMutex
+	handlers map[string]HandlerFunc[T]
+}
+
+// Item объект передаваемый групповому обработчику
+type Item[T any] struct {
+	//объект
+	Object T
+	//ключ объекта
+	ID string
+	//номер попытки выполнения
+	Attempt int
+}
+
+// BatchHandlerFunc групповой обработчик объектов, возвращает для каждого объекта
+// ошибку выполнения или nil если объект выполнен успешно, порядок ошибок совпадает
+// с порядком объектов
+type BatchHandlerFunc[T any] func(ctx context.Context, items []Item[T]) []error
+
+// batchOptions параметры группового выполнения объектов
+type batchOptions[T any] struct {
+	handler BatchHandlerFunc[T]
+	//максимальное количество объектов в группе
+	maxSize int
+	//максимальное время ожидания заполнения группы
+	linger time.Duration
+	//группа объектов выполняется в настоящее время
+	running atomic.Bool
+}
+
+// WALFsyncPolicy политика сброса записей журнала упреждающей записи на диск
+type WALFsyncPolicy int
+
+const (
+	//WALFsyncAlways сброс на диск после каждой записи, наиболее надежный и медленный вариант
+	WALFsyncAlways WALFsyncPolicy = iota
+	//WALFsyncEverySecond сброс на диск не чаще одного раза в секунду, при сбое могут
+	//быть потеряны записи сделанные за последнюю секунду
+	WALFsyncEverySecond
+	//WALFsyncNever сброс на диск выполняется операционной системой
+	WALFsyncNever
+)
+
+// walOptions параметры журнала упреждающей записи
+type walOptions struct {
+	//директория журнала
+	dir    string
+	policy wal.FsyncPolicy
+	//максимальный размер сегмента журнала, в байтах
+	segmentSize int64
+}
+
+// HandlerFactory пользовательская функция, восстанавливающая вспомогательный тип, реализующий
+// интерфейс CacheStorageHandler, вместе с функцией-обёрткой выполнения, по ключу и объекту
+type HandlerFactory[T any] func(id string, obj T) CacheStorageHandler[T]
+
+// snapshotOptions параметры сохранения и восстановления состояния хранилища
+type snapshotOptions[T any] struct {
+	codec   Codec[T]
+	factory HandlerFactory[T]
+	//файл для автоматического сохранения состояния хранилища
+	path string
+	//интервал автоматического сохранения состояния хранилища
+	interval time.Duration
+}
+
+// JSONCodec кодирование объектов типа T в формат JSON
+type JSONCodec[T any] struct{}
+
+// historyOptions параметры хранения предыдущих версий объектов
+type historyOptions[T any] struct {
+	//функция копирования объекта, нужна если MatchingAndReplacement изменяет объект
+	//из кэша, а не создает новый
+	clone func(T) T
+	//количество хранимых версий, 0 - версии не хранятся
+	size int
 }
 
 // queueObjects очередь объектов
 type queueObjects[T any] struct {
 	mutex    sync.RWMutex
-	storages []CacheStorageHandler[T]
+	storages []queueItem[T]
+	//отложенные объекты, время выполнения которых еще не наступило
+	delayed delayedQueue[T]
+}
+
+// delayedQueue отложенные объекты очереди, упорядоченные по времени выполнения (min-heap)
+type delayedQueue[T any] []queueItem[T]
+
+// executionRegistry контексты выполняющихся в настоящее время функций объектов
+type executionRegistry struct {
+	mutex      sync.Mutex
+	executions map[string]execution
+}
+
+// execution контекст выполняющейся функции объекта и функция его отмены
+type execution struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	//номер запуска функции объекта
+	generation uint64
+}
+
+// configControl изменение параметров хранилища во время работы
+type configControl struct {
+	//изменение параметров выполняется последовательно, так как параметры
+	//проверяются совместно
+	mutex sync.Mutex
+	//уведомление цикла автоматической обработки об изменении интервала
+	tickChanged chan struct{}
 }
 
-// cacheStorages кэш данных
+// adaptiveConcurrency параметры адаптивного управления количеством одновременно
+// выполняемых функций
+type adaptiveConcurrency struct {
+	//адаптивное управление включено
+	enabled bool
+	//границы количества одновременно выполняемых функций
+	minLimit int
+	maxLimit int
+	//время выполнения функции, при превышении которого количество уменьшается
+	targetLatency time.Duration
+}
+
+// Config текущие параметры хранилища
+type Config struct {
+	//максимальное время жизни объекта в кэше
+	MaxTtl time.Duration
+	//интервал автоматической обработки
+	TimeTick time.Duration
+	//максимальный размер кэша
+	MaxSize int
+	//количество одновременно выполняемых функций, при значении меньше 2
+	//функции выполняются синхронно
+	Concurrency int
+}
+
+// queueItem объект в очереди
+type queueItem[T any] struct {
+	handler CacheStorageHandler[T]
+	//время добавления объекта в очередь
+	timePush time.Time
+	//время, раньше которого объект не может быть выполнен
+	notBefore time.Time
+}
+
+// cacheStorages кэш данных, разделённый на сегменты (shards) по хешу ключа
 type cacheStorages[T any] struct {
+	//сегменты кэша, каждый со своей блокировкой
+	shards []*cacheShard[T]
+	//максимальный размер кэша при привышении которого выполняется удаление самой старой записи
+	maxSize atomic.Int64
+	//счетчик запусков функций объектов, из него назначается номер каждого запуска
+	generation atomic.Uint64
+}
+
+// cacheShard сегмент кэша
+type cacheShard[T any] struct {
 	mutex sync.RWMutex
 	//основное хранилище
-	storages map[string]storageParameters[T]
-	//максимальный размер кэша при привышении которого выполняется удаление самой старой записи
-	maxSize int
+	storages cacheBackend[T]
+	//индекс всех объектов сегмента упорядоченный по timeExpiry
+	expiry *expiryIndex
+	//индекс объектов ожидающих выполнения (функция которых не выполняется и не была
+	//успешно выполнена) упорядоченный по timeExpiry
+	ready *expiryIndex
+	//индекс периодических объектов ожидающих очередного запуска, упорядоченный по
+	//времени следующего запуска
+	recurring *expiryIndex
 }
 
 type storageParameters[T any] struct {
@@ -35,6 +389,8 @@ type storageParameters[T any] struct {
 	originalObject T
 	//фунция-обертка выполнения
 	cacheFunc func(int) bool
+	//имя зарегистрированного обработчика, если задано, используется вместо cacheFunc
+	handlerName string
 	//количество попыток выполнения функции
 	numberExecutionAttempts int
 	//общее время истечения жизни, время по истечению которого объект удаляется в любом
@@ -46,6 +402,93 @@ type storageParameters[T any] struct {
 	isCompletedSuccessfully bool
 	//статус выполнения
 	isExecution bool
+	//ошибка последней неудачной попытки выполнения функции
+	lastError error
+	//предыдущие версии объекта, от более старой к более новой
+	history []ObjectVersion[T]
+	//отпечаток объекта, если вспомогательный тип реализует интерфейс Hasher
+	hash []byte
+	//расписание периодического объекта, для остальных объектов nil
+	schedule *cron.Schedule
+	//время следующего запуска периодического объекта, нулевое во время очередного запуска
+	timeNextRun time.Time
+	//результат последнего выполнения функции, если вспомогательный тип реализует
+	//интерфейс ResultHandler
+	result any
+	//номер последнего запуска функции, результат выполнения с другим номером, например
+	//завершившегося после отмены объекта и добавления нового объекта с тем же ключом,
+	//отбрасывается
+	generation uint64
+}
+
+// ObjectVersion предыдущая версия объекта, замененная в кэше более новой
+type ObjectVersion[T any] struct {
+	//объект
+	Object T
+	//время с которого объект находился в кэше
+	Time time.Time
+}
+
+// EntryInfo согласованное состояние объекта находящегося в кэше на момент запроса
+type EntryInfo[T any] struct {
+	//исходный объект над которым выполняются действия
+	Object T
+	//основное время, время добавления или замены объекта в кэше
+	TimeMain time.Time
+	//время истечения жизни объекта
+	TimeExpiry time.Time
+	//время следующего запуска периодического объекта, нулевое во время очередного запуска
+	TimeNextRun time.Time
+	//ошибка последней неудачной попытки выполнения функции
+	LastError error
+	//результат последнего выполнения функции, после восстановления состояния хранилища
+	//результат представлен в виде json.RawMessage
+	Result any
+	//ключ объекта
+	ID string
+	//имя зарегистрированного обработчика объекта
+	HandlerName string
+	//расписание периодического объекта, для остальных объектов пустое
+	Schedule string
+	//количество попыток выполнения функции
+	NumberExecutionAttempts int
+	//результат выполнения
+	IsCompletedSuccessfully bool
+	//статус выполнения
+	IsExecution bool
+}
+
+// DeadLetter объект, функция которого выполнялась, но не была выполнена успешно,
+// перемещенный в очередь недоставленных объектов при удалении из кэша
+type DeadLetter[T any] struct {
+	//исходный объект над которым выполнялись действия
+	Object T
+	//основное время, время добавления или замены объекта в кэше
+	TimeMain time.Time
+	//время перемещения объекта в очередь недоставленных объектов
+	TimeDead time.Time
+	//время удаления объекта из очереди недоставленных объектов
+	TimeExpiry time.Time
+	//ошибка последней неудачной попытки выполнения функции
+	LastError error
+	//ключ объекта
+	ID string
+	//имя зарегистрированного обработчика объекта
+	HandlerName string
+	//причина удаления объекта из кэша
+	Reason Reason
+	//количество попыток выполнения функции
+	NumberExecutionAttempts int
+}
+
+// EntryError ошибка операции с объектом по ключу, Err содержит ErrNotFound или
+// ErrWrongState, что позволяет проверять ошибку с помощью errors.Is
+type EntryError struct {
+	Err error
+	//название операции
+	Op string
+	//ключ объекта
+	Key string
 }
 
 type cacheOptions[T any] func(*CacheStorageWithQueue[T]) error
@@ -0,0 +1,388 @@
+package cachingstoragewithqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/av-belyakov/cachingstoragewithqueue/internal/wal"
+)
+
+// количество сегментов журнала после последней контрольной точки, при превышении
+// которого выполняется сжатие журнала
+const walMaxSegments = 4
+
+// типы записей журнала упреждающей записи
+const (
+	walOpPush       = "push"
+	walOpAdmit      = "admit"
+	walOpReject     = "reject"
+	walOpComplete   = "complete"
+	walOpDelete     = "delete"
+	walOpCleanQueue = "clean_queue"
+	walOpCleanCache = "clean_cache"
+	walOpState      = "state"
+	walOpCancel     = "cancel"
+)
+
+// walRecord запись журнала упреждающей записи
+type walRecord struct {
+	TimeMain                *time.Time            `json:"time_main,omitempty"`
+	TimeExpiry              *time.Time            `json:"time_expiry,omitempty"`
+	NotBefore               *time.Time            `json:"not_before,omitempty"`
+	TimeNextRun             *time.Time            `json:"time_next_run,omitempty"`
+	Op                      string                `json:"op"`
+	ID                      string                `json:"id,omitempty"`
+	HandlerName             string                `json:"handler_name,omitempty"`
+	Schedule                string                `json:"schedule,omitempty"`
+	LastError               string                `json:"last_error,omitempty"`
+	Result                  json.RawMessage       `json:"result,omitempty"`
+	Object                  []byte                `json:"object,omitempty"`
+	Hash                    []byte                `json:"hash,omitempty"`
+	History                 []snapshotVersionItem `json:"history,omitempty"`
+	NumberExecutionAttempts int                   `json:"number_execution_attempts,omitempty"`
+	IsCompletedSuccessfully bool                  `json:"is_completed_successfully,omitempty"`
+}
+
+// CompactWAL сжимает журнал упреждающей записи, записывает контрольную точку с текущим
+// состоянием очереди и кэша и удаляет ранее записанные сегменты журнала. Выполняется
+// автоматически, при превышении количества сегментов после последней контрольной точки
+func (c *CacheStorageWithQueue[T]) CompactWAL() error {
+	if c.wal == nil {
+		return errors.New("the write-ahead log is not enabled, use the WithWAL option")
+	}
+
+	//новый сегмент начинается и состояние копируется под блокировкой очереди и кэша,
+	//поэтому в контрольную точку попадают ровно те изменения, что записаны до нового сегмента
+	c.queue.mutex.RLock()
+	c.cache.rLockAll()
+	num, err := c.wal.Rotate()
+	queue, cache := c.copyStateLocked()
+	c.cache.rUnlockAll()
+	c.queue.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	records := make([][]byte, 0, len(queue)+len(cache))
+	for _, v := range queue {
+		record, err := c.newWALRecordFromQueueItem(v)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, b)
+	}
+
+	for key, storage := range cache {
+		record, err := c.newWALRecordFromStorage(walOpState, key, storage)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, b)
+	}
+
+	return c.wal.WriteCheckpoint(num, records)
+}
+
+// openWAL восстанавливает состояние очереди и кэша из журнала упреждающей записи,
+// открывает журнал для записи и записывает контрольную точку восстановленного состояния.
+// Если количество объектов восстанавливаемого кэша превышает максимальный размер кэша,
+// возвращается ошибка
+func (c *CacheStorageWithQueue[T]) openWAL() error {
+	var (
+		queue []walRecord
+		cache = map[string]walRecord{}
+	)
+
+	err := wal.Replay(c.walOpts.dir, func(b []byte) error {
+		var record walRecord
+		if err := json.Unmarshal(b, &record); err != nil {
+			return fmt.Errorf("error decoding a write-ahead log record: %w", err)
+		}
+
+		//удаление из восстанавливаемой очереди первого объекта с заданным ключом
+		removeFromQueue := func(key string) {
+			if i := slices.IndexFunc(queue, func(r walRecord) bool { return r.ID == key }); i >= 0 {
+				queue = slices.Delete(queue, i, i+1)
+			}
+		}
+
+		switch record.Op {
+		case walOpPush:
+			queue = append(queue, record)
+
+		case walOpAdmit:
+			removeFromQueue(record.ID)
+			cache[record.ID] = record
+
+		case walOpReject, walOpCancel:
+			removeFromQueue(record.ID)
+
+		case walOpState:
+			cache[record.ID] = record
+
+		case walOpComplete:
+			if state, ok := cache[record.ID]; ok {
+				state.NumberExecutionAttempts = record.NumberExecutionAttempts
+				state.IsCompletedSuccessfully = record.IsCompletedSuccessfully
+				state.LastError = record.LastError
+				state.TimeNextRun = record.TimeNextRun
+				state.Result = record.Result
+				cache[record.ID] = state
+			}
+
+		case walOpDelete:
+			delete(cache, record.ID)
+
+		case walOpCleanQueue:
+			queue = nil
+
+		case walOpCleanCache:
+			cache = map[string]walRecord{}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(cache) > c.cache.getMaxSize() {
+		return fmt.Errorf("the cache restored from the write-ahead log contains %d objects, which exceeds the maximum cache size %d", len(cache), c.cache.getMaxSize())
+	}
+
+	for _, record := range queue {
+		obj, err := c.snapshot.codec.Decode(record.Object)
+		if err != nil {
+			return fmt.Errorf("error decoding a queue object with key ID '%s': %w", record.ID, err)
+		}
+
+		c.queue.push(queueItem[T]{handler: c.snapshot.factory(record.ID, obj), timePush: time.Now(), notBefore: timeOrZero(record.NotBefore)})
+	}
+
+	for key, record := range cache {
+		obj, err := c.snapshot.codec.Decode(record.Object)
+		if err != nil {
+			return fmt.Errorf("error decoding a cache object with key ID '%s': %w", key, err)
+		}
+
+		schedule, err := parseSchedule(key, record.Schedule)
+		if err != nil {
+			return err
+		}
+
+		storage := storageParameters[T]{
+			originalObject:          obj,
+			cacheFunc:               c.objectFunc(key, c.snapshot.factory(key, obj)),
+			handlerName:             record.HandlerName,
+			hash:                    record.Hash,
+			timeMain:                timeOrZero(record.TimeMain),
+			timeExpiry:              timeOrZero(record.TimeExpiry),
+			timeNextRun:             timeOrZero(record.TimeNextRun),
+			schedule:                schedule,
+			result:                  decodeResult(record.Result),
+			numberExecutionAttempts: record.NumberExecutionAttempts,
+			isCompletedSuccessfully: record.IsCompletedSuccessfully,
+		}
+		if record.LastError != "" {
+			storage.lastError = errors.New(record.LastError)
+		}
+
+		if storage.history, err = decodeHistory(c.snapshot.codec, key, record.History); err != nil {
+			return err
+		}
+
+		c.cache.shard(key).setRestored(key, storage)
+	}
+
+	l, err := wal.Open(c.walOpts.dir, c.walOpts.policy, time.Second, c.walOpts.segmentSize)
+	if err != nil {
+		return err
+	}
+	c.wal = l
+
+	return c.CompactWAL()
+}
+
+// maintainWAL периодическое обслуживание журнала, сброс на диск и сжатие
+func (c *CacheStorageWithQueue[T]) maintainWAL() {
+	if c.wal == nil {
+		return
+	}
+
+	if c.walOpts.policy == wal.FsyncInterval {
+		if err := c.wal.Sync(); err != nil {
+			c.logWALError(err)
+		}
+	}
+
+	if c.wal.SegmentsSinceCheckpoint() > walMaxSegments {
+		if err := c.CompactWAL(); err != nil {
+			c.logWALError(err)
+		}
+	}
+}
+
+// walPush запись о добавлении объекта в очередь
+func (c *CacheStorageWithQueue[T]) walPush(item queueItem[T]) {
+	if c.wal == nil {
+		return
+	}
+
+	record, err := c.newWALRecordFromQueueItem(item)
+	if err != nil {
+		c.logWALError(err)
+
+		return
+	}
+
+	c.walAppend(record)
+}
+
+// walStore запись о добавлении или изменении объекта в кэше, с полным состоянием объекта
+func (c *CacheStorageWithQueue[T]) walStore(op, key string, storage storageParameters[T]) {
+	if c.wal == nil {
+		return
+	}
+
+	record, err := c.newWALRecordFromStorage(op, key, storage)
+	if err != nil {
+		c.logWALError(err)
+
+		return
+	}
+
+	c.walAppend(record)
+}
+
+// walComplete запись о завершении выполнения функции объекта
+func (c *CacheStorageWithQueue[T]) walComplete(key string, storage storageParameters[T]) {
+	if c.wal == nil {
+		return
+	}
+
+	record := walRecord{
+		Op:                      walOpComplete,
+		ID:                      key,
+		NumberExecutionAttempts: storage.numberExecutionAttempts,
+		IsCompletedSuccessfully: storage.isCompletedSuccessfully,
+		TimeNextRun:             optionalTime(storage.timeNextRun),
+	}
+	if storage.lastError != nil {
+		record.LastError = storage.lastError.Error()
+	}
+
+	result, err := encodeResult(key, storage.result)
+	if err != nil {
+		c.logWALError(err)
+	}
+	record.Result = result
+
+	c.walAppend(record)
+}
+
+// walOp запись операции с объектом без сохранения самого объекта
+func (c *CacheStorageWithQueue[T]) walOp(op, key string) {
+	if c.wal == nil {
+		return
+	}
+
+	c.walAppend(walRecord{Op: op, ID: key})
+}
+
+// walDelete запись об удалении объектов из кэша
+func (c *CacheStorageWithQueue[T]) walDelete(keys ...string) {
+	for _, key := range keys {
+		c.walOp(walOpDelete, key)
+	}
+}
+
+// walClean запись об очистке очереди или кэша
+func (c *CacheStorageWithQueue[T]) walClean(op string) {
+	c.walOp(op, "")
+}
+
+// walAppend добавляет запись в журнал
+func (c *CacheStorageWithQueue[T]) walAppend(record walRecord) {
+	b, err := json.Marshal(record)
+	if err != nil {
+		c.logWALError(err)
+
+		return
+	}
+
+	if err := c.wal.Append(b); err != nil {
+		c.logWALError(err)
+	}
+}
+
+// newWALRecordWithObject запись журнала с закодированным объектом
+func (c *CacheStorageWithQueue[T]) newWALRecordWithObject(op, key string, obj T) (walRecord, error) {
+	b, err := c.snapshot.codec.Encode(obj)
+	if err != nil {
+		return walRecord{}, fmt.Errorf("error encoding an object with key ID '%s': %w", key, err)
+	}
+
+	return walRecord{Op: op, ID: key, Object: b}, nil
+}
+
+// newWALRecordFromQueueItem запись журнала о добавлении объекта в очередь
+func (c *CacheStorageWithQueue[T]) newWALRecordFromQueueItem(item queueItem[T]) (walRecord, error) {
+	record, err := c.newWALRecordWithObject(walOpPush, item.handler.GetID(), item.handler.GetObject())
+	if err != nil {
+		return record, err
+	}
+
+	record.NotBefore = optionalTime(item.notBefore)
+
+	return record, nil
+}
+
+// newWALRecordFromStorage запись журнала с полным состоянием объекта кэша
+func (c *CacheStorageWithQueue[T]) newWALRecordFromStorage(op, key string, storage storageParameters[T]) (walRecord, error) {
+	record, err := c.newWALRecordWithObject(op, key, storage.originalObject)
+	if err != nil {
+		return record, err
+	}
+
+	record.Hash = storage.hash
+	record.HandlerName = storage.handlerName
+	record.TimeMain = optionalTime(storage.timeMain)
+	record.TimeExpiry = optionalTime(storage.timeExpiry)
+	record.TimeNextRun = optionalTime(storage.timeNextRun)
+	record.Schedule = storage.schedule.String()
+	record.NumberExecutionAttempts = storage.numberExecutionAttempts
+	record.IsCompletedSuccessfully = storage.isCompletedSuccessfully
+	if storage.lastError != nil {
+		record.LastError = storage.lastError.Error()
+	}
+
+	if record.Result, err = encodeResult(key, storage.result); err != nil {
+		return record, err
+	}
+
+	if record.History, err = encodeHistory(c.snapshot.codec, key, storage.history); err != nil {
+		return record, err
+	}
+
+	return record, nil
+}
+
+// logWALError логирование ошибки журнала упреждающей записи
+func (c *CacheStorageWithQueue[T]) logWALError(err error) {
+	c.logError(logEventWALError, err)
+}